@@ -0,0 +1,368 @@
+//-----------------------------------------------------------------------------
+/*
+
+swr - software renderer
+
+Render a wavefront obj model to an image file.
+
+  swr -i obj/african_head.obj -o head.png -mode phong
+
+The camera looks at the center of the model from the -eye direction and is
+placed so the model fills the view. -orbit and -dolly move it from there.
+The output format is set by the file extension (png, jpg, gif, tif, bmp).
+
+  swr -scene scenes/heads.yaml
+
+renders the passes of a scene file (see the scene package) instead.
+
+Exit codes:
+
+0 - success
+1 - rendering error
+2 - bad command line
+3 - the model, scene or textures could not be read
+4 - the output file could not be written
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/render"
+	"github.com/deadsy/sw_render/scene"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+	"github.com/disintegration/imaging"
+)
+
+//-----------------------------------------------------------------------------
+
+// exit codes
+const (
+	exit_ok     = 0
+	exit_error  = 1
+	exit_usage  = 2
+	exit_input  = 3
+	exit_output = 4
+)
+
+//-----------------------------------------------------------------------------
+
+// flag values
+
+// parse n comma separated floats
+func parse_floats(s string, n int) ([]float32, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("\"%s\": need %d comma separated values", s, n)
+	}
+	x := make([]float32, n)
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\": bad value \"%s\"", s, f)
+		}
+		x[i] = float32(v)
+	}
+	return x, nil
+}
+
+// parse x,y,z
+func parse_v3(s string) (vec.V3, error) {
+	x, err := parse_floats(s, 3)
+	if err != nil {
+		return vec.V3{}, err
+	}
+	return vec.V3{x[0], x[1], x[2]}, nil
+}
+
+// parse x,y
+func parse_v2(s string) (vec.V2, error) {
+	x, err := parse_floats(s, 2)
+	if err != nil {
+		return vec.V2{}, err
+	}
+	return vec.V2{x[0], x[1]}, nil
+}
+
+// parse an rrggbb or rrggbbaa hex color (with an optional leading #)
+func parse_color(s string) (vec.V4, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 && len(h) != 8 {
+		return vec.V4{}, fmt.Errorf("\"%s\": color must be rrggbb or rrggbbaa", s)
+	}
+	x, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return vec.V4{}, fmt.Errorf("\"%s\": bad hex color", s)
+	}
+	if len(h) == 6 {
+		x = x<<8 | 0xff
+	}
+	c := vec.V4{float32(x >> 24), float32(x >> 16 & 0xff), float32(x >> 8 & 0xff), float32(x & 0xff)}
+	return c.Scale(1.0 / 255), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// options are the render settings from the command line
+type options struct {
+	input, output string
+	scene         string
+	width, height int
+	eye           vec.V3 // direction from the model center to the camera
+	orbit         vec.V2 // yaw, pitch in degrees
+	dolly         float32
+	fov           float32 // degrees
+	ortho         bool
+	mode          render.Mode
+	bg            vec.V4
+	light         vec.V3 // direction towards the light
+	workers       int
+	verbose       int
+}
+
+// parse the command line
+func parse_args(args []string, stderr io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("swr", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := &options{}
+	fs.StringVar(&opts.input, "i", "", "input model (wavefront obj)")
+	fs.StringVar(&opts.scene, "scene", "", "scene file (json or yaml) with the render passes")
+	fs.StringVar(&opts.output, "o", "output.png", "output image")
+	fs.IntVar(&opts.width, "width", 750, "image width in pixels")
+	fs.IntVar(&opts.height, "height", 750, "image height in pixels")
+	eye := fs.String("eye", "0,0,1", "direction from the model center to the camera (x,y,z)")
+	orbit := fs.String("orbit", "0,0", "camera orbit about the model center (yaw,pitch in degrees)")
+	fov := fs.Float64("fov", 45, "vertical field of view in degrees")
+	dolly := fs.Float64("dolly", 1, "scale the camera distance (< 1 moves closer)")
+	fs.BoolVar(&opts.ortho, "ortho", false, "orthographic projection")
+	mode := fs.String("mode", "phong", "render mode ("+strings.Join(render.Mode_Names(), ", ")+")")
+	bg := fs.String("bg", "000000", "background color (rrggbb or rrggbbaa)")
+	light := fs.String("light", "-1,1,1", "direction towards the light (x,y,z)")
+	fs.IntVar(&opts.workers, "workers", runtime.NumCPU(), "rasterizer goroutines (0 for serial rasterization)")
+	fs.IntVar(&opts.verbose, "v", 0, "verbosity (0 quiet, 1 summary, 2 detail)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: swr -i model.obj [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if opts.input != "" && opts.scene != "" {
+		return nil, errors.New("use an input model (-i) or a scene (-scene), not both")
+	}
+	if opts.input == "" && opts.scene == "" {
+		return nil, errors.New("no input model (-i) or scene (-scene)")
+	}
+	if _, err := imaging.FormatFromFilename(opts.output); err != nil {
+		return nil, fmt.Errorf("%s: unknown image format", opts.output)
+	}
+	if opts.width <= 0 || opts.height <= 0 {
+		return nil, fmt.Errorf("bad image size %dx%d", opts.width, opts.height)
+	}
+	if opts.workers < 0 {
+		return nil, fmt.Errorf("bad worker count %d", opts.workers)
+	}
+	if *fov <= 0 || *fov >= 180 {
+		return nil, fmt.Errorf("bad field of view %g", *fov)
+	}
+	if *dolly <= 0 {
+		return nil, fmt.Errorf("bad dolly %g", *dolly)
+	}
+	opts.fov = float32(*fov)
+	opts.dolly = float32(*dolly)
+	if opts.mode, err = render.Parse_Mode(*mode); err != nil {
+		return nil, err
+	}
+	if opts.eye, err = parse_v3(*eye); err != nil {
+		return nil, fmt.Errorf("-eye %s", err)
+	}
+	if opts.eye.Length() == 0 {
+		return nil, errors.New("-eye must not be zero")
+	}
+	if opts.orbit, err = parse_v2(*orbit); err != nil {
+		return nil, fmt.Errorf("-orbit %s", err)
+	}
+	if opts.light, err = parse_v3(*light); err != nil {
+		return nil, fmt.Errorf("-light %s", err)
+	}
+	if opts.bg, err = parse_color(*bg); err != nil {
+		return nil, fmt.Errorf("-bg %s", err)
+	}
+	return opts, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// return the degrees as radians
+func radians(x float32) float32 {
+	return x * math.Pi / 180
+}
+
+// return the camera for the options looking at an object
+func new_camera(opts *options, obj *wavefront.Object) *render.Camera {
+	c := render.New_Camera()
+	c.Fov = radians(opts.fov)
+	c.Aspect = float32(opts.width) / float32(opts.height)
+	if opts.ortho {
+		c.Projection = render.Orthographic
+	}
+	// frame from the eye direction, then move the camera
+	c.Eye = opts.eye
+	c.Frame_Object(obj)
+	c.Orbit(radians(opts.orbit[0]), radians(opts.orbit[1]))
+	d := c.Distance()
+	c.Dolly(opts.dolly)
+	// keep the object between the near and far planes
+	r := c.Far - d
+	c.Far = c.Distance() + r
+	c.Near = max(c.Distance()-r, c.Far/1000)
+	return c
+}
+
+// return the lighting for the options
+func new_lighting(opts *options) *light.Lighting {
+	return &light.Lighting{
+		Model:   light.Blinn_Phong,
+		Ambient: vec.V3{0.1, 0.1, 0.1},
+		Lights:  []*light.Light{light.New_Directional(opts.light.Neg(), vec.V3{1, 1, 1}, 1)},
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// log_func prints a message at a verbosity level
+type log_func func(level int, format string, a ...any)
+
+// render the passes of a scene and write the output images, returning the exit code
+func run_scene(opts *options, logf log_func, stderr io.Writer) int {
+	start := time.Now()
+	s, err := scene.Load(opts.scene)
+	if err != nil {
+		fmt.Fprintf(stderr, "swr: %s\n", err)
+		return exit_input
+	}
+	s.Workers = opts.workers
+	logf(2, "%s: %d objects, %d lights, %d cameras, %d passes (%s)\n", opts.scene, len(s.Objects),
+		len(s.Lighting.Lights), len(s.Cameras), len(s.Passes), time.Since(start).Round(time.Millisecond))
+	for _, p := range s.Passes {
+		start := time.Now()
+		img, err := s.Render(p)
+		if err != nil {
+			fmt.Fprintf(stderr, "swr: %s: %s: %s\n", opts.scene, p.Name, err)
+			return exit_input
+		}
+		if err := os.MkdirAll(filepath.Dir(p.Output), 0755); err != nil {
+			fmt.Fprintf(stderr, "swr: %s\n", err)
+			return exit_output
+		}
+		if err := imaging.Save(img, p.Output); err != nil {
+			fmt.Fprintf(stderr, "swr: %s\n", err)
+			return exit_output
+		}
+		logf(1, "%s: %d objects, %s, %s, %dx%d -> %s (%s)\n", p.Name, len(p.Objects), p.Camera.Name, p.Mode,
+			s.Width, s.Height, p.Output, time.Since(start).Round(time.Millisecond))
+	}
+	return exit_ok
+}
+
+// render the model and write the output image, returning the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+
+	opts, err := parse_args(args, stderr)
+	if err == flag.ErrHelp {
+		return exit_ok
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "swr: %s\n", err)
+		return exit_usage
+	}
+
+	logf := func(level int, format string, a ...any) {
+		if opts.verbose >= level {
+			fmt.Fprintf(stdout, format, a...)
+		}
+	}
+	if opts.scene != "" {
+		return run_scene(opts, logf, stderr)
+	}
+	start := time.Now()
+
+	obj, err := wavefront.Read(opts.input, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "swr: %s: %s\n", opts.input, err)
+		return exit_input
+	}
+	logf(2, "%s\n", obj)
+	mesh := obj.Mesh()
+	if len(mesh.Faces) == 0 {
+		fmt.Fprintf(stderr, "swr: %s: no faces to render\n", opts.input)
+		return exit_input
+	}
+
+	camera := new_camera(opts, obj)
+	logf(2, "camera: %+v\n", *camera)
+
+	ms := render.New_Mesh_Shader(mesh, vec.Identity_M4(), camera.View_Proj(), opts.light)
+	s, err := render.New_Shader(opts.mode, ms, new_lighting(opts), camera)
+	if err != nil {
+		fmt.Fprintf(stderr, "swr: %s\n", err)
+		return exit_input
+	}
+	if ts, ok := s.(*render.Textured_Shader); ok && !ts.Textured() {
+		logf(1, "%s: no textures, using material colors\n", opts.input)
+	}
+
+	fb := render.New_Framebuffer(opts.width, opts.height, render.Bottom_Left)
+	color_buf, err := fb.Attach("color", render.RGBA8)
+	if err != nil {
+		fmt.Fprintf(stderr, "swr: %s\n", err)
+		return exit_error
+	}
+	color_buf.Clear_Value = opts.bg
+	if opts.mode != render.Wireframe {
+		if _, err := fb.Attach("depth", render.Depth32F); err != nil {
+			fmt.Fprintf(stderr, "swr: %s\n", err)
+			return exit_error
+		}
+	}
+	fb.Clear()
+
+	p := fb.Pipeline()
+	p.Workers = opts.workers
+	render.Draw_Mesh(p, s)
+
+	img, _ := fb.Image("color")
+	if err := imaging.Save(img, opts.output); err != nil {
+		fmt.Fprintf(stderr, "swr: %s\n", err)
+		return exit_output
+	}
+	logf(1, "%s: %d triangles, %s, %dx%d -> %s (%s)\n", opts.input, len(mesh.Faces), opts.mode,
+		opts.width, opts.height, opts.output, time.Since(start).Round(time.Millisecond))
+	return exit_ok
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadsy/sw_render/render"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/disintegration/imaging"
+)
+
+func Test_Parse(t *testing.T) {
+	if v, err := parse_v3("1, -2.5,3"); err != nil || v != (vec.V3{1, -2.5, 3}) {
+		t.Error("FAIL")
+	}
+	if _, err := parse_v3("1,2"); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := parse_v2("1,x"); err == nil {
+		t.Error("FAIL")
+	}
+	if c, err := parse_color("#ff0000"); err != nil || c != (vec.V4{1, 0, 0, 1}) {
+		t.Error("FAIL")
+	}
+	if c, err := parse_color("00ff0000"); err != nil || c != (vec.V4{0, 1, 0, 0}) {
+		t.Error("FAIL")
+	}
+	if _, err := parse_color("fff"); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Run(t *testing.T) {
+	dir := t.TempDir()
+	obj := filepath.Join(dir, "quad.obj")
+	quad := "v -1 -1 0\nv 1 -1 0\nv 1 1 0\nv -1 1 0\nf 1 2 3 4\n"
+	if err := os.WriteFile(obj, []byte(quad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.png")
+	bg := color.NRGBA{0, 0, 255, 255}
+
+	for _, mode := range render.Mode_Names() {
+		args := []string{"-i", obj, "-o", out, "-width", "32", "-height", "24", "-mode", mode, "-bg", "0000ff", "-light", "0,0,1"}
+		if run(args, io.Discard, io.Discard) != exit_ok {
+			t.Fatalf("FAIL %s", mode)
+		}
+		img, err := imaging.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 24 {
+			t.Errorf("FAIL %s", mode)
+		}
+		// the quad fills the middle of the view, the diagonal edge is through the center
+		if color.NRGBAModel.Convert(img.At(0, 0)) != bg {
+			t.Errorf("FAIL %s", mode)
+		}
+		hit := false
+		for _, p := range [][2]int{{16, 12}, {15, 11}, {15, 12}, {16, 11}} {
+			hit = hit || color.NRGBAModel.Convert(img.At(p[0], p[1])) != bg
+		}
+		if !hit {
+			t.Errorf("FAIL %s", mode)
+		}
+	}
+
+	// a view from behind is culled
+	if run([]string{"-i", obj, "-o", out, "-mode", "flat", "-eye", "0,0,-1", "-width", "8", "-height", "8"}, io.Discard, io.Discard) != exit_ok {
+		t.Fatal("FAIL")
+	}
+	if img, err := imaging.Open(out); err != nil || color.NRGBAModel.Convert(img.At(4, 4)) != (color.NRGBA{0, 0, 0, 255}) {
+		t.Error("FAIL")
+	}
+
+	// scene passes
+	scene := filepath.Join(dir, "scene.json")
+	data := `{"objects": [{"model": "quad.obj"}], "passes": [{"mode": "normals", "output": "out/n.png"}]}`
+	if err := os.WriteFile(scene, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if run([]string{"-scene", scene}, io.Discard, io.Discard) != exit_ok {
+		t.Error("FAIL")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "n.png")); err != nil {
+		t.Error("FAIL")
+	}
+
+	// exit codes
+	for _, test := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{}, exit_usage},
+		{[]string{"-i", obj, "extra"}, exit_usage},
+		{[]string{"-i", obj, "-mode", "cartoon"}, exit_usage},
+		{[]string{"-i", obj, "-width", "0"}, exit_usage},
+		{[]string{"-i", obj, "-o", "out.xyz"}, exit_usage},
+		{[]string{"-i", obj, "-eye", "0,0,0"}, exit_usage},
+		{[]string{"-bogus"}, exit_usage},
+		{[]string{"-i", obj, "-scene", scene}, exit_usage},
+		{[]string{"-scene", obj}, exit_input},
+		{[]string{"-h"}, exit_ok},
+		{[]string{"-i", filepath.Join(dir, "none.obj")}, exit_input},
+		{[]string{"-i", obj, "-o", filepath.Join(dir, "none", "out.png")}, exit_output},
+	} {
+		if code := run(test.args, io.Discard, io.Discard); code != test.code {
+			t.Errorf("FAIL %v %d", test.args, code)
+		}
+	}
+}
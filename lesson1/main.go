@@ -1,149 +1,90 @@
+//-----------------------------------------------------------------------------
+/*
+
+Lesson 1: wireframe line drawing of a model.
+
+  go run ./lesson1 -i obj/gopher.obj -o gopher.png -width 1000
+
+The camera, render mode and lighting options are in cmd/swr.
+
+*/
+//-----------------------------------------------------------------------------
+
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"os"
 
-	"github.com/deadsy/sw_render/utils"
+	"github.com/deadsy/sw_render/raster"
 	"github.com/deadsy/sw_render/vec"
 	"github.com/deadsy/sw_render/wavefront"
 	"github.com/disintegration/imaging"
 )
 
-const pixels_x = 1000
 const pixels_ofs = 5
 
-type plot_func func(int, int)
-
-// bresenham's line algorithm
-// dx > 0, dy >= 0, dx >= dy
-func bresenham_line(dx, dy int, plot plot_func) {
-	err_y := 2 * dy
-	err_x := 2 * dx
-	y := 0
-	err := 0
-	for x := 0; x <= dx; x++ {
-		plot(x, y)
-		err += err_y
-		if err >= dx {
-			y += 1
-			err -= err_x
-		}
-	}
-}
+// pen widths for line and point elements
+const line_width = 3
+const point_size = 7
 
-// major x-axis, quadrant 0
-func plot_x0(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(x, y int) {
-		img.SetNRGBA(ofs[0]+x, ofs[1]+y, color)
-	}
+// object to image mapping: offset, scale and then flip the y-axis down
+func Obj2Img(ofs vec.V3f, scale, height float32) vec.M4 {
+	flip := vec.Translate(vec.V3f{0, height - 1, 0}).Mul(vec.Scale(vec.V3f{1, -1, 1}))
+	return flip.Mul(vec.Scale(vec.V3f{scale, scale, scale})).Mul(vec.Translate(ofs))
 }
 
-// major x-axis, quadrant 1
-func plot_x1(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(x, y int) {
-		img.SetNRGBA(ofs[0]-x, ofs[1]+y, color)
-	}
-}
-
-// major x-axis, quadrant 2
-func plot_x2(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(x, y int) {
-		img.SetNRGBA(ofs[0]-x, ofs[1]-y, color)
-	}
-}
-
-// major x-axis, quadrant 3
-func plot_x3(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(x, y int) {
-		img.SetNRGBA(ofs[0]+x, ofs[1]-y, color)
-	}
-}
-
-// major y-axis, quadrant 0
-func plot_y0(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(y, x int) {
-		img.SetNRGBA(ofs[0]+x, ofs[1]+y, color)
-	}
-}
-
-// major y-axis, quadrant 1
-func plot_y1(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(y, x int) {
-		img.SetNRGBA(ofs[0]-x, ofs[1]+y, color)
-	}
-}
-
-// major y-axis, quadrant 2
-func plot_y2(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(y, x int) {
-		img.SetNRGBA(ofs[0]-x, ofs[1]-y, color)
-	}
+// transform a point to image coordinates
+func Project(m vec.M4, v vec.V3f) vec.V2i {
+	p := m.Mul_Point(v)
+	return vec.V2i{int(p[0]), int(p[1])}
 }
 
-// major y-axis, quadrant 3
-func plot_y3(ofs vec.V2i, img *image.NRGBA, color color.NRGBA) plot_func {
-	return func(y, x int) {
-		img.SetNRGBA(ofs[0]+x, ofs[1]-y, color)
+// return the pen color for a material
+func pen_color(m *wavefront.Material, c color.NRGBA) color.NRGBA {
+	if m == nil {
+		return c
 	}
+	k := m.Kd.Scale(255)
+	return color.NRGBA{uint8(k[0]), uint8(k[1]), uint8(k[2]), 255}
 }
 
-func line(a, b vec.V2i, img *image.NRGBA, color color.NRGBA) {
-	if a.Equal(b) {
-		return
-	}
-	x := b.Sub(a)
-	if utils.Abs(x[0]) >= utils.Abs(x[1]) {
-		// major x-axis
-		if x[0] >= 0 {
-			if x[1] >= 0 {
-				bresenham_line(x[0], x[1], plot_x0(a, img, color))
-			} else {
-				bresenham_line(x[0], -x[1], plot_x3(a, img, color))
-			}
-		} else {
-			if x[1] >= 0 {
-				bresenham_line(-x[0], x[1], plot_x1(a, img, color))
-			} else {
-				bresenham_line(-x[0], -x[1], plot_x2(a, img, color))
-			}
+// draw the line and point elements of an object
+func draw_elements(obj *wavefront.Object, img *image.NRGBA, m vec.M4, c color.NRGBA) {
+	for i := 0; i < obj.Len_L(); i++ {
+		pen := raster.Pen{Width: line_width, Color: pen_color(obj.Get_L_Material(i), c)}
+		pts := make([]vec.V2i, obj.Len_LV(i))
+		for j := range pts {
+			pts[j] = Project(m, obj.Get_LV(i, j).ToV3())
 		}
-	} else {
-		// major y-axis
-		if x[0] >= 0 {
-			if x[1] >= 0 {
-				bresenham_line(x[1], x[0], plot_y0(a, img, color))
-			} else {
-				bresenham_line(-x[1], x[0], plot_y3(a, img, color))
-			}
-		} else {
-			if x[1] >= 0 {
-				bresenham_line(x[1], -x[0], plot_y1(a, img, color))
-			} else {
-				bresenham_line(-x[1], -x[0], plot_y2(a, img, color))
-			}
+		pen.Polyline(img, pts)
+	}
+	for i := 0; i < obj.Len_P(); i++ {
+		pen := raster.Pen{Width: point_size, Color: pen_color(obj.Get_P_Material(i), c)}
+		for j := 0; j < obj.Len_PV(i); j++ {
+			pen.Point(img, Project(m, obj.Get_PV(i, j).ToV3()))
 		}
 	}
 }
 
-// object to image mapping
-func Obj2Img(v, ofs vec.V3f, scale float32) vec.V2i {
-	p := v.Sum(ofs).Scale(scale)
-	return vec.V2i{int(p[0]), int(p[1])}
-}
-
 func main() {
 
-	//objfile := "../obj/gopher.obj"
-	objfile := "../obj/african_head.obj"
-	//objfile := "../obj/test_triangle.obj"
-	imgfile := "output.png"
+	objfile := flag.String("i", "../obj/african_head.obj", "input obj file")
+	imgfile := flag.String("o", "output.png", "output image file")
+	width := flag.Int("width", 1000, "image width in pixels")
+	flag.Parse()
+	if flag.NArg() != 0 || *width <= pixels_ofs {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pixels_x := float32(*width)
 
-	obj, err := wavefront.Read(objfile)
+	obj, err := wavefront.Read(*objfile, &wavefront.Options{Triangulate: true})
 	if err != nil {
-		fmt.Printf("%s: %s\n", objfile, err)
+		fmt.Printf("%s: %s\n", *objfile, err)
 		os.Exit(1)
 	}
 
@@ -161,12 +102,13 @@ func main() {
 
 	// work out the image size
 	img_size := obj_range.Scale(scale)
-	img_size = img_size.Sum(vec.V3f{pixels_ofs, pixels_ofs, pixels_ofs})
+	img_size = img_size.Add(vec.V3f{pixels_ofs, pixels_ofs, pixels_ofs})
 	fmt.Printf("img_size: %+v\n", img_size)
 
 	white := color.NRGBA{255, 255, 255, 255}
 	black := color.NRGBA{0, 0, 0, 255}
 	img := imaging.New(int(img_size[0]), int(img_size[1]), black)
+	m := Obj2Img(obj_ofs, scale, float32(int(img_size[1])))
 
 	// iterate over the object faces
 	for i := 0; i < obj.Len_F(); i++ {
@@ -176,22 +118,24 @@ func main() {
 		v1 := obj.Get_V(i, 1).ToV3()
 		v2 := obj.Get_V(i, 2).ToV3()
 
-		p0 := Obj2Img(v0, obj_ofs, scale)
-		p1 := Obj2Img(v1, obj_ofs, scale)
-		p2 := Obj2Img(v2, obj_ofs, scale)
+		p0 := Project(m, v0)
+		p1 := Project(m, v1)
+		p2 := Project(m, v2)
 
 		// p0 to p1
-		line(p0, p1, img, white)
+		raster.Line(img, p0, p1, white)
 		// p1 to p2
-		line(p1, p2, img, white)
+		raster.Line(img, p1, p2, white)
 		// p2 to p0
-		line(p2, p0, img, white)
+		raster.Line(img, p2, p0, white)
 	}
 
-	img = imaging.FlipV(img)
-	err = imaging.Save(img, imgfile)
+	// polylines and points
+	draw_elements(obj, img, m, white)
+
+	err = imaging.Save(img, *imgfile)
 	if err != nil {
-		fmt.Printf("unable to save %s, %s\n", imgfile, err)
+		fmt.Printf("unable to save %s, %s\n", *imgfile, err)
 		os.Exit(1)
 	}
 
@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/deadsy/sw_render/raster"
 	"github.com/deadsy/sw_render/vec"
 )
 
@@ -65,6 +66,7 @@ func test_barycentric() {
 	test_bs(vec.V2{1, 1}, vec.V2{3, 1}, vec.V2{1, 3}, vec.V2{1 + k, 1 + k})
 }
 
+// edge function rasterization with a top-left fill rule
 func triangle2(v [3]*vec.V2, img *image.NRGBA, color color.NRGBA) {
-
+	raster.Fill_Triangle(img, [3]vec.V2{*v[0], *v[1], *v[2]}, color)
 }
@@ -0,0 +1,147 @@
+//-----------------------------------------------------------------------------
+/*
+
+Lights
+
+Directional lights shine in a fixed direction from infinitely far away.
+Point lights shine in all directions from a position, with the intensity
+falling off with distance as 1 / (constant + linear * d + quadratic * d^2).
+Spot lights are point lights limited to a cone about a direction. The
+intensity is full within the inner cone angle, falls off smoothly to zero at
+the outer cone angle, and is zero outside it.
+
+*/
+//-----------------------------------------------------------------------------
+
+package light
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Kind is the type of a light
+type Kind int
+
+const (
+	Directional Kind = iota
+	Point
+	Spot
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Directional:
+		return "directional"
+	case Point:
+		return "point"
+	case Spot:
+		return "spot"
+	}
+	return "unknown"
+}
+
+// Light is a light source
+type Light struct {
+	Kind      Kind
+	Color     vec.V3
+	Intensity float32
+	Position  vec.V3 // point and spot lights
+	Direction vec.V3 // unit direction the light shines in (directional and spot lights)
+	// point and spot light attenuation
+	Constant  float32
+	Linear    float32
+	Quadratic float32
+	// spot light cone half angles in radians
+	Inner float32
+	Outer float32
+}
+
+// New_Directional returns a directional light shining in a direction
+func New_Directional(dir, color vec.V3, intensity float32) *Light {
+	return &Light{
+		Kind:      Directional,
+		Color:     color,
+		Intensity: intensity,
+		Direction: dir.Normalize(),
+	}
+}
+
+// New_Point returns a point light at a position with no attenuation
+func New_Point(pos, color vec.V3, intensity float32) *Light {
+	return &Light{
+		Kind:      Point,
+		Color:     color,
+		Intensity: intensity,
+		Position:  pos,
+		Constant:  1,
+	}
+}
+
+// New_Spot returns a spot light at a position shining in a direction with no attenuation
+func New_Spot(pos, dir vec.V3, inner, outer float32, color vec.V3, intensity float32) *Light {
+	return &Light{
+		Kind:      Spot,
+		Color:     color,
+		Intensity: intensity,
+		Position:  pos,
+		Direction: dir.Normalize(),
+		Constant:  1,
+		Inner:     inner,
+		Outer:     outer,
+	}
+}
+
+// Attenuation sets the attenuation factors of a point or spot light
+func (l *Light) Attenuation(constant, linear, quadratic float32) *Light {
+	l.Constant = constant
+	l.Linear = linear
+	l.Quadratic = quadratic
+	return l
+}
+
+// Incident returns the unit direction from a surface point to the light
+// and the light color arriving at the point.
+func (l *Light) Incident(p vec.V3) (vec.V3, vec.V3) {
+	c := l.Color.Scale(l.Intensity)
+	if l.Kind == Directional {
+		return l.Direction.Neg(), c
+	}
+	d := l.Position.Sub(p)
+	dist := d.Length()
+	if dist == 0 {
+		return vec.V3{}, vec.V3{}
+	}
+	d = d.Scale(1 / dist)
+	k := l.Constant + l.Linear*dist + l.Quadratic*dist*dist
+	if k > 0 {
+		c = c.Scale(1 / k)
+	}
+	if l.Kind == Spot {
+		c = c.Scale(l.cone(d.Neg().Dot(l.Direction)))
+	}
+	return d, c
+}
+
+// return the spot light intensity factor for the cosine of the angle off axis
+func (l *Light) cone(cos_angle float32) float32 {
+	cos_inner := float32(math.Cos(float64(l.Inner)))
+	cos_outer := float32(math.Cos(float64(l.Outer)))
+	if cos_angle >= cos_inner {
+		return 1
+	}
+	if cos_angle <= cos_outer {
+		return 0
+	}
+	return smoothstep((cos_angle - cos_outer) / (cos_inner - cos_outer))
+}
+
+// return a smooth step for x in [0,1]
+func smoothstep(x float32) float32 {
+	return x * x * (3 - 2*x)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,102 @@
+package light
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+const eps = 1e-5
+
+func Test_Incident(t *testing.T) {
+	white := vec.V3{1, 1, 1}
+
+	// directional
+	l := New_Directional(vec.V3{0, -2, 0}, white, 0.5)
+	d, c := l.Incident(vec.V3{5, 5, 5})
+	if !d.Equal_Eps(vec.V3{0, 1, 0}, eps) || !c.Equal_Eps(vec.V3{0.5, 0.5, 0.5}, eps) {
+		t.Errorf("FAIL %v %v", d, c)
+	}
+
+	// point with attenuation
+	l = New_Point(vec.V3{0, 2, 0}, white, 1).Attenuation(1, 0.5, 0.25)
+	d, c = l.Incident(vec.V3{0, 0, 0})
+	if !d.Equal_Eps(vec.V3{0, 1, 0}, eps) || !c.Equal_Eps(vec.V3{1.0 / 3, 1.0 / 3, 1.0 / 3}, eps) {
+		t.Errorf("FAIL %v %v", d, c)
+	}
+
+	// spot: inside the inner cone, between the cones and outside the outer cone
+	l = New_Spot(vec.V3{0, 10, 0}, vec.V3{0, -1, 0}, math.Pi/8, math.Pi/4, white, 1)
+	if _, c := l.Incident(vec.V3{1, 0, 0}); c[0] != 1 {
+		t.Errorf("FAIL %v", c)
+	}
+	// half way in cosine
+	mid := math.Acos((math.Cos(math.Pi/8) + math.Cos(math.Pi/4)) / 2)
+	if _, c := l.Incident(vec.V3{10 * float32(math.Tan(mid)), 0, 0}); abs(c[0]-0.5) > 1e-4 {
+		t.Errorf("FAIL %v", c)
+	}
+	if _, c := l.Incident(vec.V3{11, 0, 0}); c[0] != 0 {
+		t.Errorf("FAIL %v", c)
+	}
+}
+
+func Test_Shade(t *testing.T) {
+	m := Material{Ka: vec.V3{1, 0, 0}, Kd: vec.V3{0, 1, 0}, Ks: vec.V3{0, 0, 1}, Ns: 10}
+	s := Lighting{
+		Ambient: vec.V3{0.1, 0.1, 0.1},
+		Lights:  []*Light{New_Directional(vec.V3{-1, -1, 0}, vec.V3{1, 1, 1}, 1)},
+	}
+	n := vec.V3{0, 1, 0}
+	k := float32(math.Sqrt(0.5))
+
+	// mirror direction: full highlight for both models
+	v := vec.V3{-1, 1, 0}.Normalize()
+	for _, model := range []Model{Blinn_Phong, Phong} {
+		s.Model = model
+		c := s.Shade(&m, vec.V3{}, n, v)
+		if !c.Equal_Eps(vec.V3{0.1, k, 1}, eps) {
+			t.Errorf("FAIL %s %v", model, c)
+		}
+	}
+
+	// off the mirror direction the phong highlight is smaller
+	v = vec.V3{0, 1, 0}
+	s.Model = Phong
+	phong := s.Shade(&m, vec.V3{}, n, v)
+	s.Model = Blinn_Phong
+	blinn := s.Shade(&m, vec.V3{}, n, v)
+	if abs(phong[2]-pow(k, 10)) > eps || abs(blinn[2]-pow(float32(math.Cos(math.Pi/8)), 10)) > eps {
+		t.Errorf("FAIL %v %v", phong, blinn)
+	}
+
+	// facing away from the light: ambient only
+	if c := s.Shade(&m, vec.V3{}, n.Neg(), v); !c.Equal_Eps(vec.V3{0.1, 0, 0}, eps) {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// no specular exponent: no highlight
+	m.Ns = 0
+	if c := s.Shade(&m, vec.V3{}, n, v); !c.Equal_Eps(vec.V3{0.1, k, 0}, eps) {
+		t.Errorf("FAIL %v", c)
+	}
+}
+
+func Test_From_MTL(t *testing.T) {
+	if From_MTL(nil) != Default_Material {
+		t.Error("FAIL")
+	}
+	m := From_MTL(&wavefront.Material{Kd: vec.V3{0.5, 0.5, 0.5}, Ns: 20})
+	if m.Kd != (vec.V3{0.5, 0.5, 0.5}) || m.Ns != 20 {
+		t.Error("FAIL")
+	}
+}
+
+// return the absolute value of x
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
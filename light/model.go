@@ -0,0 +1,117 @@
+//-----------------------------------------------------------------------------
+/*
+
+Lighting Models
+
+The color of a surface point is the sum of an ambient term and the diffuse
+and specular terms for each light.
+
+ambient = Ka * ambient light
+diffuse = Kd * light * max(n.l, 0)
+specular = Ks * light * max(r.v, 0)^Ns (Phong)
+specular = Ks * light * max(n.h, 0)^Ns (Blinn-Phong)
+
+n is the surface normal, l the direction to the light, v the direction to
+the viewer, r the reflection of the light about the normal and h the half
+way vector between l and v. All are unit vectors. There is no specular term
+for surfaces facing away from the light, or for materials without a specular
+exponent (Ns <= 0, as for an mtl material without Ns).
+
+*/
+//-----------------------------------------------------------------------------
+
+package light
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+//-----------------------------------------------------------------------------
+
+// Model is a specular lighting model
+type Model int
+
+const (
+	Blinn_Phong Model = iota
+	Phong
+)
+
+func (m Model) String() string {
+	switch m {
+	case Blinn_Phong:
+		return "blinn-phong"
+	case Phong:
+		return "phong"
+	}
+	return "unknown"
+}
+
+// Material has the surface reflectances for lighting
+type Material struct {
+	Ka vec.V3  // ambient color
+	Kd vec.V3  // diffuse color
+	Ks vec.V3  // specular color
+	Ns float32 // specular exponent
+}
+
+// Default_Material is a grey diffuse material with a weak highlight
+var Default_Material = Material{
+	Ka: vec.V3{1, 1, 1},
+	Kd: vec.V3{0.8, 0.8, 0.8},
+	Ks: vec.V3{0.2, 0.2, 0.2},
+	Ns: 32,
+}
+
+// From_MTL returns the lighting material for a material from an mtl file.
+// A nil material returns the default material.
+func From_MTL(m *wavefront.Material) Material {
+	if m == nil {
+		return Default_Material
+	}
+	return Material{Ka: m.Ka, Kd: m.Kd, Ks: m.Ks, Ns: m.Ns}
+}
+
+// Lighting is a set of lights with a lighting model
+type Lighting struct {
+	Model   Model
+	Ambient vec.V3 // ambient light color
+	Lights  []*Light
+}
+
+// Shade returns the color of a surface point p with unit normal n
+// seen from the unit direction v towards the viewer.
+func (s *Lighting) Shade(m *Material, p, n, v vec.V3) vec.V3 {
+	c := m.Ka.Mul(s.Ambient)
+	for _, l := range s.Lights {
+		d, lc := l.Incident(p)
+		nl := n.Dot(d)
+		if nl <= 0 {
+			continue
+		}
+		c = c.Add(m.Kd.Mul(lc).Scale(nl))
+		if m.Ns <= 0 {
+			continue
+		}
+		var k float32
+		switch s.Model {
+		case Phong:
+			k = d.Neg().Reflect(n).Dot(v)
+		default:
+			k = n.Dot(d.Add(v).Normalize())
+		}
+		if k > 0 {
+			c = c.Add(m.Ks.Mul(lc).Scale(pow(k, m.Ns)))
+		}
+	}
+	return c
+}
+
+// return x^y
+func pow(x, y float32) float32 {
+	return float32(math.Pow(float64(x), float64(y)))
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,187 @@
+//-----------------------------------------------------------------------------
+/*
+
+Homogeneous Clipping
+
+Triangles and lines are clipped in clip space against the view frustum
+planes -w <= x,y,z <= w with the Sutherland-Hodgman algorithm. New vertices
+have their positions and varyings interpolated along the clipped edge.
+No perspective varyings are interpolated in screen space. Flat varyings are
+expected to be the same at all vertices, so they are unchanged.
+
+With a guard band the x and y planes are moved out to -g*w <= x,y <= g*w.
+Only the near and far planes are then clipped exactly, and the parts of a
+triangle outside the target are skipped by the rasterizer scissor. The guard
+band keeps the window coordinates within the fixed point range, so it is
+limited to Max_Guard_Band for the target.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import "image"
+
+//-----------------------------------------------------------------------------
+
+// frustum planes
+const (
+	clip_left = iota
+	clip_right
+	clip_bottom
+	clip_top
+	clip_near
+	clip_far
+	n_clip_planes
+)
+
+// clip space polygons have at most one new vertex per plane
+const max_clip_vertices = 3 + n_clip_planes
+
+// return the signed distance of a vertex from a clip plane (>= 0 is inside)
+func clip_distance(v *Vertex, plane int, guard float32) float32 {
+	p := &v.Position
+	switch plane {
+	case clip_left:
+		return guard*p[3] + p[0]
+	case clip_right:
+		return guard*p[3] - p[0]
+	case clip_bottom:
+		return guard*p[3] + p[1]
+	case clip_top:
+		return guard*p[3] - p[1]
+	case clip_near:
+		return p[3] + p[2]
+	}
+	return p[3] - p[2]
+}
+
+// return the vertex at t along a to b
+func clip_lerp(a, b *Vertex, t float32, q []Qualifier) Vertex {
+	var v Vertex
+	v.Position = a.Position.Lerp(b.Position, t)
+	// t in screen space for no perspective varyings
+	wa, wb := a.Position[3], b.Position[3]
+	ts := t * wb / ((1-t)*wa + t*wb)
+	for i := range q {
+		k := t
+		if q[i] == No_Perspective {
+			k = ts
+		}
+		v.Varyings[i] = a.Varyings[i] + k*(b.Varyings[i]-a.Varyings[i])
+	}
+	return v
+}
+
+// return the guard band factor (exact clipping for <= 1)
+func guard_factor(guard float32) float32 {
+	return max(guard, 1)
+}
+
+// Max_Guard_Band returns the largest guard band for a target that keeps the
+// window coordinates within the fixed point range of the rasterizer.
+func Max_Guard_Band(r image.Rectangle) float32 {
+	// the farthest window coordinate is the target center +/- guard * size/2
+	e := max(abs_int(r.Min.X), abs_int(r.Min.Y), abs_int(r.Max.X), abs_int(r.Max.Y))
+	size := max(r.Dx(), r.Dy(), 1)
+	return max(2*float32(max_window-e)/float32(size), 1)
+}
+
+// return the absolute value of x
+func abs_int(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+//-----------------------------------------------------------------------------
+
+// Clipper clips polygons and lines against the view frustum
+type Clipper struct {
+	Guard_Band float32 // x/y planes at +/- Guard_Band * w (0 or 1 for exact clipping, see Max_Guard_Band)
+	buf        [2][max_clip_vertices]Vertex
+}
+
+// Triangle clips a triangle with varyings qualified by q.
+// It returns the vertices of the clipped convex polygon (nil if none remain).
+// The returned slice is valid until the next call.
+func (c *Clipper) Triangle(v *[3]Vertex, q []Qualifier) []Vertex {
+	guard := guard_factor(c.Guard_Band)
+
+	// trivial accept and reject
+	var out_all, out_any int
+	out_all = (1 << n_clip_planes) - 1
+	for k := range v {
+		var out int
+		for plane := 0; plane < n_clip_planes; plane++ {
+			if clip_distance(&v[k], plane, guard) < 0 {
+				out |= 1 << plane
+			}
+		}
+		out_all &= out
+		out_any |= out
+	}
+	if out_all != 0 {
+		return nil
+	}
+	in := c.buf[0][:3]
+	copy(in, v[:])
+	if out_any == 0 {
+		return in
+	}
+
+	out := c.buf[1][:0]
+	for plane := 0; plane < n_clip_planes; plane++ {
+		if out_any&(1<<plane) == 0 {
+			continue
+		}
+		out = out[:0]
+		for i := range in {
+			a := &in[i]
+			b := &in[(i+1)%len(in)]
+			da := clip_distance(a, plane, guard)
+			db := clip_distance(b, plane, guard)
+			if da >= 0 {
+				out = append(out, *a)
+			}
+			// interpolate from the inside vertex so a shared edge clips to the same point
+			if da >= 0 && db < 0 {
+				out = append(out, clip_lerp(a, b, da/(da-db), q))
+			}
+			if da < 0 && db >= 0 {
+				out = append(out, clip_lerp(b, a, db/(db-da), q))
+			}
+		}
+		if len(out) < 3 {
+			return nil
+		}
+		in, out = out, in[:0]
+	}
+	return in
+}
+
+// Line clips a line with varyings qualified by q.
+// It returns false if no part of the line remains.
+func (c *Clipper) Line(a, b *Vertex, q []Qualifier) (Vertex, Vertex, bool) {
+	guard := guard_factor(c.Guard_Band)
+	t0, t1 := float32(0), float32(1)
+	for plane := 0; plane < n_clip_planes; plane++ {
+		da := clip_distance(a, plane, guard)
+		db := clip_distance(b, plane, guard)
+		switch {
+		case da < 0 && db < 0:
+			return Vertex{}, Vertex{}, false
+		case da < 0:
+			t0 = max(t0, da/(da-db))
+		case db < 0:
+			t1 = min(t1, da/(da-db))
+		}
+	}
+	if t0 > t1 {
+		return Vertex{}, Vertex{}, false
+	}
+	return clip_lerp(a, b, t0, q), clip_lerp(a, b, t1, q), true
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,118 @@
+//-----------------------------------------------------------------------------
+/*
+
+Depth Buffer
+
+A depth buffer holds a float32 depth for each pixel of an image.
+A fragment is drawn if its depth passes the depth test against the stored
+depth. If it passes and writes are enabled the stored depth is replaced.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+//-----------------------------------------------------------------------------
+
+// Depth_Func compares a fragment depth with the stored depth
+type Depth_Func int
+
+const (
+	Depth_Less    Depth_Func = iota // pass if z < stored z
+	Depth_LEqual                    // pass if z <= stored z
+	Depth_Greater                   // pass if z > stored z
+	Depth_Always                    // always pass
+	Depth_Never                     // never pass
+)
+
+// Depth_Buffer is a per-pixel depth buffer
+type Depth_Buffer struct {
+	Width, Height int
+	Func          Depth_Func // depth test function
+	Write         bool       // write the depth of fragments that pass the test
+	Clear_Value   float32    // depth value set by Clear
+	z             []float32
+}
+
+// New_Depth_Buffer returns a cleared depth buffer.
+// The defaults are a less-than test, writes enabled and a clear value of 1.
+func New_Depth_Buffer(width, height int) *Depth_Buffer {
+	d := &Depth_Buffer{
+		Width:       width,
+		Height:      height,
+		Func:        Depth_Less,
+		Write:       true,
+		Clear_Value: 1,
+		z:           make([]float32, width*height),
+	}
+	d.Clear()
+	return d
+}
+
+// Resize changes the buffer size and clears it
+func (d *Depth_Buffer) Resize(width, height int) {
+	d.Width = width
+	d.Height = height
+	if cap(d.z) >= width*height {
+		d.z = d.z[:width*height]
+	} else {
+		d.z = make([]float32, width*height)
+	}
+	d.Clear()
+}
+
+// Clear sets all depths to the clear value
+func (d *Depth_Buffer) Clear() {
+	for i := range d.z {
+		d.z[i] = d.Clear_Value
+	}
+}
+
+// return true if x, y is within the buffer
+func (d *Depth_Buffer) inside(x, y int) bool {
+	return x >= 0 && x < d.Width && y >= 0 && y < d.Height
+}
+
+// Get returns the stored depth at x, y
+func (d *Depth_Buffer) Get(x, y int) float32 {
+	return d.z[y*d.Width+x]
+}
+
+// Set stores a depth at x, y (regardless of the test and write mask)
+func (d *Depth_Buffer) Set(x, y int, z float32) {
+	d.z[y*d.Width+x] = z
+}
+
+// return true if z passes the depth test against the stored depth s
+func (d *Depth_Buffer) pass(z, s float32) bool {
+	switch d.Func {
+	case Depth_Less:
+		return z < s
+	case Depth_LEqual:
+		return z <= s
+	case Depth_Greater:
+		return z > s
+	case Depth_Always:
+		return true
+	}
+	return false
+}
+
+// Test returns true if a fragment at x, y with depth z passes the depth test.
+// The depth is stored if the test passes and writes are enabled.
+// Fragments outside the buffer fail.
+func (d *Depth_Buffer) Test(x, y int, z float32) bool {
+	if !d.inside(x, y) {
+		return false
+	}
+	i := y*d.Width + x
+	if !d.pass(z, d.z[i]) {
+		return false
+	}
+	if d.Write {
+		d.z[i] = z
+	}
+	return true
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,155 @@
+//-----------------------------------------------------------------------------
+/*
+
+Edge Function Triangle Rasterization
+
+Vertices are snapped to fixed point with sub_bits of sub-pixel precision.
+Each pixel center in the triangle bounding box is tested against the three
+edge functions. A pixel center exactly on an edge is only covered if the
+edge is a top or left edge, so pixels on an edge shared by two triangles are
+drawn exactly once. The edge arithmetic is exact integer arithmetic.
+
+The edge functions are positive inside a counter clockwise triangle with the
+y-axis up. A top edge is horizontal with the interior below it, a left edge
+has the interior to its right.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// sub-pixel precision bits
+const sub_bits = 8
+const sub_one = 1 << sub_bits
+const sub_half = sub_one / 2
+
+// Window coordinates within +/- max_window pixels are < 2^29 in fixed point,
+// so the edge function products (< 2^60) and their sums fit in an int64.
+const max_window = 1 << (29 - sub_bits)
+
+// Fragment_Func is called for each covered pixel.
+// b has the barycentric weights of the pixel center for each vertex.
+type Fragment_Func func(x, y int, b vec.V3)
+
+// convert to fixed point
+func to_fixed(x float32) int64 {
+	return int64(math.Round(float64(x) * sub_one))
+}
+
+// return floor(a / b) for b > 0
+func floor_div(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// a fixed point edge function
+type edge_func struct {
+	dx, dy int64 // edge direction
+	bias   int64 // 0 for top-left edges, -1 otherwise
+}
+
+// return the edge function from a to b
+func new_edge(a, b [2]int64) edge_func {
+	e := edge_func{dx: b[0] - a[0], dy: b[1] - a[1], bias: -1}
+	if e.dy < 0 || (e.dy == 0 && e.dx < 0) {
+		// top or left edge
+		e.bias = 0
+	}
+	return e
+}
+
+// return the edge function value at p relative to a point a on the edge
+func (e edge_func) eval(a, p [2]int64) int64 {
+	return e.dx*(p[1]-a[1]) - e.dy*(p[0]-a[0])
+}
+
+// Triangle calls f for each pixel in clip whose center is covered by the triangle.
+// Either winding order is drawn. Degenerate triangles cover nothing.
+func Triangle(p [3]vec.V2, clip image.Rectangle, f Fragment_Func) {
+
+	var v [3][2]int64
+	for i := range p {
+		v[i] = [2]int64{to_fixed(p[i][0]), to_fixed(p[i][1])}
+	}
+
+	// keep the vertex order for the barycentric weights
+	idx := [3]int{0, 1, 2}
+	area := new_edge(v[0], v[1]).eval(v[0], v[2])
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		// make it counter clockwise
+		v[1], v[2] = v[2], v[1]
+		idx[1], idx[2] = idx[2], idx[1]
+		area = -area
+	}
+
+	// edge i is opposite vertex i
+	e := [3]edge_func{
+		new_edge(v[1], v[2]),
+		new_edge(v[2], v[0]),
+		new_edge(v[0], v[1]),
+	}
+	origin := [3][2]int64{v[1], v[2], v[0]}
+
+	// pixels with centers in the bounding box
+	min_x := min(v[0][0], v[1][0], v[2][0])
+	max_x := max(v[0][0], v[1][0], v[2][0])
+	min_y := min(v[0][1], v[1][1], v[2][1])
+	max_y := max(v[0][1], v[1][1], v[2][1])
+	x0 := int(floor_div(min_x-sub_half+sub_one-1, sub_one))
+	x1 := int(floor_div(max_x-sub_half, sub_one))
+	y0 := int(floor_div(min_y-sub_half+sub_one-1, sub_one))
+	y1 := int(floor_div(max_y-sub_half, sub_one))
+	x0 = max(x0, clip.Min.X)
+	x1 = min(x1, clip.Max.X-1)
+	y0 = max(y0, clip.Min.Y)
+	y1 = min(y1, clip.Max.Y-1)
+	if x0 > x1 || y0 > y1 {
+		return
+	}
+
+	// edge values at the first pixel center and steps per pixel
+	c := [2]int64{int64(x0)*sub_one + sub_half, int64(y0)*sub_one + sub_half}
+	var row, step_x, step_y [3]int64
+	for i := range e {
+		row[i] = e[i].eval(origin[i], c)
+		step_x[i] = -e[i].dy * sub_one
+		step_y[i] = e[i].dx * sub_one
+	}
+
+	k := 1 / float32(area)
+	for y := y0; y <= y1; y++ {
+		w := row
+		for x := x0; x <= x1; x++ {
+			if w[0]+e[0].bias >= 0 && w[1]+e[1].bias >= 0 && w[2]+e[2].bias >= 0 {
+				var b vec.V3
+				b[idx[0]] = float32(w[0]) * k
+				b[idx[1]] = float32(w[1]) * k
+				b[idx[2]] = float32(w[2]) * k
+				f(x, y, b)
+			}
+			w[0] += step_x[0]
+			w[1] += step_x[1]
+			w[2] += step_x[2]
+		}
+		row[0] += step_y[0]
+		row[1] += step_y[1]
+		row[2] += step_y[2]
+	}
+}
+
+//-----------------------------------------------------------------------------
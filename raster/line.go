@@ -0,0 +1,144 @@
+//-----------------------------------------------------------------------------
+/*
+
+Line and Point Drawing
+
+Lines are drawn with Bresenham's algorithm. Wide lines and points are drawn
+by stamping a round brush at each pixel of the line.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/deadsy/sw_render/utils"
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+type plot_func func(int, int)
+
+// bresenham's line algorithm
+// dx >= 0, dy >= 0, dx >= dy
+func bresenham_line(dx, dy int, plot plot_func) {
+	err_y := 2 * dy
+	err_x := 2 * dx
+	y := 0
+	err := 0
+	for x := 0; x <= dx; x++ {
+		plot(x, y)
+		err += err_y
+		if err >= dx {
+			y += 1
+			err -= err_x
+		}
+	}
+}
+
+// map octant coordinates relative to ofs back to the plane
+// sx, sy are the x and y signs, swap is true for a major y-axis
+func octant(ofs vec.V2i, sx, sy int, swap bool, plot plot_func) plot_func {
+	if swap {
+		return func(y, x int) {
+			plot(ofs[0]+sx*x, ofs[1]+sy*y)
+		}
+	}
+	return func(x, y int) {
+		plot(ofs[0]+sx*x, ofs[1]+sy*y)
+	}
+}
+
+// Line_Func calls plot for each pixel of the line from a to b
+func Line_Func(a, b vec.V2i, plot func(x, y int)) {
+	x := b.Sub(a)
+	sx := 1
+	if x[0] < 0 {
+		sx = -1
+	}
+	sy := 1
+	if x[1] < 0 {
+		sy = -1
+	}
+	dx := utils.Abs(x[0])
+	dy := utils.Abs(x[1])
+	if dx >= dy {
+		// major x-axis
+		bresenham_line(dx, dy, octant(a, sx, sy, false, plot))
+	} else {
+		// major y-axis
+		bresenham_line(dy, dx, octant(a, sx, sy, true, plot))
+	}
+}
+
+// Line draws a single pixel wide line from a to b
+func Line(img *image.NRGBA, a, b vec.V2i, color color.NRGBA) {
+	Line_Func(a, b, func(x, y int) {
+		img.SetNRGBA(x, y, color)
+	})
+}
+
+//-----------------------------------------------------------------------------
+
+// Pen sets the width and color for lines and points
+type Pen struct {
+	Width int // line width and point diameter in pixels (<= 1 for single pixels)
+	Color color.NRGBA
+}
+
+// return the pixel offsets for a round brush of the pen width
+func (p *Pen) brush() []vec.V2i {
+	if p.Width <= 1 {
+		return []vec.V2i{{0, 0}}
+	}
+	var b []vec.V2i
+	c := float32(p.Width-1) / 2
+	r2 := float32(p.Width*p.Width) / 4
+	for j := 0; j < p.Width; j++ {
+		for i := 0; i < p.Width; i++ {
+			dx := float32(i) - c
+			dy := float32(j) - c
+			if dx*dx+dy*dy <= r2 {
+				b = append(b, vec.V2i{i - (p.Width-1)/2, j - (p.Width-1)/2})
+			}
+		}
+	}
+	return b
+}
+
+// return a plot function that stamps the brush
+func (p *Pen) plot(img *image.NRGBA) plot_func {
+	brush := p.brush()
+	return func(x, y int) {
+		for _, d := range brush {
+			img.SetNRGBA(x+d[0], y+d[1], p.Color)
+		}
+	}
+}
+
+// Line draws a line from a to b
+func (p *Pen) Line(img *image.NRGBA, a, b vec.V2i) {
+	Line_Func(a, b, p.plot(img))
+}
+
+// Polyline draws lines through a list of points
+func (p *Pen) Polyline(img *image.NRGBA, pts []vec.V2i) {
+	plot := p.plot(img)
+	if len(pts) == 1 {
+		plot(pts[0][0], pts[0][1])
+	}
+	for i := 1; i < len(pts); i++ {
+		Line_Func(pts[i-1], pts[i], plot)
+	}
+}
+
+// Point draws a point at a
+func (p *Pen) Point(img *image.NRGBA, a vec.V2i) {
+	p.plot(img)(a[0], a[1])
+}
+
+//-----------------------------------------------------------------------------
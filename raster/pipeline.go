@@ -0,0 +1,299 @@
+//-----------------------------------------------------------------------------
+/*
+
+Triangle Pipeline
+
+The pipeline runs the vertex stage of a shader for each triangle, maps the
+clip space positions to window coordinates, culls, rasterizes, depth tests
+and runs the fragment stage for each covered pixel.
+
+NDC x and y in [-1,1] map to the target bounds with the y-axis up.
+NDC z in [-1,1] maps to a window depth in [0,1].
+Counter clockwise triangles in window coordinates are front facing.
+
+Triangles and lines are clipped against the view frustum (see clip.go).
+Triangles are then rasterized with the edge function rasterizer, lines with
+Bresenham's algorithm.
+
+Smooth varyings are interpolated with perspective correction: the screen
+space barycentric weights are divided by the vertex clip w and renormalized.
+No perspective varyings use the screen space weights. Flat varyings are copied
+from the provoking vertex to the other vertices before clipping.
+
+If both the shader and the target have multiple outputs, all outputs are
+set. Otherwise only the first output is set.
+
+With Workers > 0 triangles are rasterized in parallel screen tiles (see
+tile.go). The output is the same as for serial rasterization.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Cull_Mode selects the triangles discarded by facing
+type Cull_Mode int
+
+const (
+	Cull_None  Cull_Mode = iota // draw all triangles
+	Cull_Back                   // discard back facing triangles
+	Cull_Front                  // discard front facing triangles
+)
+
+// Pipeline draws shaded triangles into a target
+type Pipeline struct {
+	Target     Target
+	Depth      *Depth_Buffer // optional depth buffer
+	Cull       Cull_Mode
+	Guard_Band float32   // x/y clip planes at +/- Guard_Band * w (0 or 1 for exact clipping, limited to Max_Guard_Band)
+	Provoking  Provoking // vertex that supplies flat varyings
+	Workers    int       // tile rasterizer goroutines (0 rasterizes each triangle after its setup)
+	Tile_Size  int       // tile width and height in pixels (0 for 64)
+	clip       Clipper
+	// multiple render targets
+	mrt_shader MRT_Shader
+	mrt_target MRT_Target
+	out        []vec.V4
+}
+
+// a vertex mapped to window coordinates
+type window_vertex struct {
+	xy    vec.V2
+	z     float32 // window depth
+	inv_w float32 // 1/w for perspective correction
+	v     *Vertex
+}
+
+// map a clip space vertex to window coordinates
+func (p *Pipeline) window(v *Vertex) window_vertex {
+	r := p.Target.Bounds()
+	c := v.Position
+	k := 1 / c[3]
+	return window_vertex{
+		xy: vec.V2{
+			float32(r.Min.X) + (c[0]*k+1)*0.5*float32(r.Dx()),
+			float32(r.Min.Y) + (c[1]*k+1)*0.5*float32(r.Dy()),
+		},
+		z:     (c[2]*k + 1) * 0.5,
+		inv_w: k,
+		v:     v,
+	}
+}
+
+// set up the draw state for a shader
+func (p *Pipeline) setup(s Shader) {
+	p.clip.Guard_Band = min(p.Guard_Band, Max_Guard_Band(p.Target.Bounds()))
+	p.mrt_shader, _ = s.(MRT_Shader)
+	p.mrt_target, _ = p.Target.(MRT_Target)
+	n := 1
+	if p.mrt_target != nil {
+		n = max(p.mrt_target.Outputs(), 1)
+	}
+	if cap(p.out) < n {
+		p.out = make([]vec.V4, n)
+	}
+	p.out = p.out[:n]
+}
+
+// Draw draws n triangles with a shader
+func (p *Pipeline) Draw(s Shader, n int) {
+	p.setup(s)
+	q := s.Varyings()
+	if p.Workers > 0 {
+		p.draw_tiles(s, q, n)
+		return
+	}
+	r := p.Target.Bounds()
+	p.setup_triangles(s, q, n, func(i int, front bool, w [3]*window_vertex) {
+		f := Fragment{Front: front, Tri: i}
+		p.triangle(s, &f, q, w, r, p.out)
+	})
+}
+
+// run the vertex stage for n triangles, then clip, map to window coordinates
+// and cull them. emit is called for each window triangle in draw order.
+func (p *Pipeline) setup_triangles(s Shader, q []Qualifier, n int, emit func(i int, front bool, w [3]*window_vertex)) {
+	var w [max_clip_vertices]window_vertex
+	for i := 0; i < n; i++ {
+		v := [3]Vertex{s.Vertex(i, 0), s.Vertex(i, 1), s.Vertex(i, 2)}
+		p.provoke(v[:], q)
+		poly := p.clip.Triangle(&v, q)
+		if poly == nil || !p.in_front(poly) {
+			continue
+		}
+		for k := range poly {
+			w[k] = p.window(&poly[k])
+		}
+		// the polygon is planar, so all of its triangles have the same facing
+		var area float32
+		for k := range poly {
+			area += w[k].xy.Cross(w[(k+1)%len(poly)].xy)
+		}
+		front := area > 0
+		if (p.Cull == Cull_Back && !front) || (p.Cull == Cull_Front && front) {
+			continue
+		}
+		for k := 1; k+1 < len(poly); k++ {
+			emit(i, front, [3]*window_vertex{&w[0], &w[k], &w[k+1]})
+		}
+	}
+}
+
+// Draw_Lines draws n lines with a shader.
+// Vertex k (0,1) of line i is the line end point.
+func (p *Pipeline) Draw_Lines(s Shader, n int) {
+	p.setup(s)
+	q := s.Varyings()
+	r := p.Target.Bounds()
+	for i := 0; i < n; i++ {
+		v := [2]Vertex{s.Vertex(i, 0), s.Vertex(i, 1)}
+		p.provoke(v[:], q)
+		a, b, ok := p.clip.Line(&v[0], &v[1], q)
+		if !ok || !p.in_front([]Vertex{a, b}) {
+			continue
+		}
+		w := [3]*window_vertex{}
+		wa, wb := p.window(&a), p.window(&b)
+		w[0], w[1], w[2] = &wa, &wb, &wb
+		d := wb.xy.Sub(wa.xy)
+		k := d.Dot(d)
+		if k > 0 {
+			k = 1 / k
+		}
+		f := Fragment{Front: true, Tri: i}
+		f.dbx = vec.V3{-d[0] * k, d[0] * k, 0}
+		f.dby = vec.V3{-d[1] * k, d[1] * k, 0}
+		pa := vec.V2i{int(floor(wa.xy[0])), int(floor(wa.xy[1]))}
+		pb := vec.V2i{int(floor(wb.xy[0])), int(floor(wb.xy[1]))}
+		Line_Func(pa, pb, func(x, y int) {
+			if !(image.Point{x, y}.In(r)) {
+				return
+			}
+			// position of the pixel center along the line
+			c := vec.V2{float32(x) + 0.5, float32(y) + 0.5}
+			t := clamp(c.Sub(wa.xy).Dot(d)*k, 0, 1)
+			p.fragment(s, &f, q, x, y, vec.V3{1 - t, t, 0}, w, p.out)
+		})
+	}
+}
+
+// copy the flat varyings of the provoking vertex to all vertices of a primitive
+func (p *Pipeline) provoke(v []Vertex, q []Qualifier) {
+	k := len(v) - 1
+	if p.Provoking == Provoking_First {
+		k = 0
+	}
+	for j := range q {
+		if q[j] == Flat {
+			for i := range v {
+				v[i].Varyings[j] = v[k].Varyings[j]
+			}
+		}
+	}
+}
+
+// return true if all vertices have w > 0
+func (p *Pipeline) in_front(v []Vertex) bool {
+	for k := range v {
+		if v[k].Position[3] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rasterize the part of a triangle in window coordinates within clip
+func (p *Pipeline) triangle(s Shader, f *Fragment, q []Qualifier, w [3]*window_vertex, clip image.Rectangle, out []vec.V4) {
+	xy := [3]vec.V2{w[0].xy, w[1].xy, w[2].xy}
+	// weight gradients
+	area := xy[1].Sub(xy[0]).Cross(xy[2].Sub(xy[0]))
+	if area == 0 {
+		return
+	}
+	k := 1 / area
+	for i := range xy {
+		a, b := xy[(i+1)%3], xy[(i+2)%3]
+		f.dbx[i] = (a[1] - b[1]) * k
+		f.dby[i] = (b[0] - a[0]) * k
+	}
+	Triangle(xy, clip, func(x, y int, b vec.V3) {
+		p.fragment(s, f, q, x, y, b, w, out)
+	})
+}
+
+// depth test and shade a fragment with screen space weights b.
+// out holds the shader outputs.
+func (p *Pipeline) fragment(s Shader, f *Fragment, q []Qualifier, x, y int, b vec.V3, w [3]*window_vertex, out []vec.V4) {
+
+	f.X, f.Y = x, y
+	// clipping leaves the depth within [0,1] up to rounding
+	f.Z = clamp(b[0]*w[0].z+b[1]*w[1].z+b[2]*w[2].z, 0, 1)
+	if p.Depth != nil && !(p.Depth.inside(x, y) && p.Depth.pass(f.Z, p.Depth.Get(x, y))) {
+		return
+	}
+
+	f.q, f.b, f.w = q, b, w
+	// perspective correct weights
+	bp := b.Mul(vec.V3{w[0].inv_w, w[1].inv_w, w[2].inv_w})
+	bp = bp.Scale(1 / (bp[0] + bp[1] + bp[2]))
+	v0, v1, v2 := &w[0].v.Varyings, &w[1].v.Varyings, &w[2].v.Varyings
+	for j := range q {
+		switch q[j] {
+		case Smooth:
+			f.Varyings[j] = bp[0]*v0[j] + bp[1]*v1[j] + bp[2]*v2[j]
+		case No_Perspective:
+			f.Varyings[j] = b[0]*v0[j] + b[1]*v1[j] + b[2]*v2[j]
+		case Flat:
+			f.Varyings[j] = v0[j]
+		}
+	}
+
+	if p.mrt_shader != nil {
+		for i := range out {
+			out[i] = vec.V4{}
+		}
+		if !p.mrt_shader.Fragment_MRT(f, out) {
+			return
+		}
+	} else {
+		c, ok := s.Fragment(f)
+		if !ok {
+			return
+		}
+		out[0] = c
+	}
+
+	if p.Depth != nil && p.Depth.Write {
+		p.Depth.Set(x, y, f.Z)
+	}
+	if p.mrt_shader != nil && p.mrt_target != nil {
+		for i, c := range out {
+			p.mrt_target.Set_Output(i, x, y, c)
+		}
+	} else {
+		p.Target.Set(x, y, out[0])
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// return x clamped to [lo, hi]
+func clamp(x, lo, hi float32) float32 {
+	return min(max(x, lo), hi)
+}
+
+// return floor(x)
+func floor(x float32) float32 {
+	return float32(math.Floor(float64(x)))
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,646 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+// return the absolute value of x
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// return the pixels plotted for a line
+func line_pixels(a, b vec.V2i) []vec.V2i {
+	var p []vec.V2i
+	Line_Func(a, b, func(x, y int) {
+		p = append(p, vec.V2i{x, y})
+	})
+	return p
+}
+
+// count the pixels set to a color
+func count_pixels(img *image.NRGBA, c color.NRGBA) int {
+	n := 0
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.NRGBAAt(x, y) == c {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func Test_Line(t *testing.T) {
+	a := vec.V2i{10, 10}
+	ends := []vec.V2i{
+		{20, 13}, {20, 7}, {0, 13}, {0, 7},
+		{13, 20}, {7, 20}, {13, 0}, {7, 0},
+		{10, 10}, {20, 10}, {10, 0},
+	}
+	for _, b := range ends {
+		p := line_pixels(a, b)
+		// the line is contiguous from a to b
+		if !p[0].Equal(a) || !p[len(p)-1].Equal(b) {
+			t.Errorf("FAIL %v", b)
+		}
+		for i := 1; i < len(p); i++ {
+			d := p[i].Sub(p[i-1])
+			if d[0] < -1 || d[0] > 1 || d[1] < -1 || d[1] > 1 {
+				t.Errorf("FAIL %v", b)
+			}
+		}
+	}
+	if len(line_pixels(vec.V2i{0, 0}, vec.V2i{7, 3})) != 8 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Pen(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	pen := Pen{Width: 1, Color: white}
+	pen.Line(img, vec.V2i{5, 5}, vec.V2i{30, 5})
+	if count_pixels(img, white) != 26 {
+		t.Error("FAIL")
+	}
+
+	// a wide horizontal line covers width rows
+	img = image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	pen = Pen{Width: 3, Color: white}
+	pen.Line(img, vec.V2i{5, 20}, vec.V2i{30, 20})
+	for _, y := range []int{19, 20, 21} {
+		if img.NRGBAAt(15, y) != white {
+			t.Error("FAIL")
+		}
+	}
+	if img.NRGBAAt(15, 18) == white || img.NRGBAAt(15, 22) == white {
+		t.Error("FAIL")
+	}
+
+	// points are round
+	img = image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	pen = Pen{Width: 5, Color: white}
+	pen.Point(img, vec.V2i{20, 20})
+	if img.NRGBAAt(20, 20) != white || img.NRGBAAt(22, 20) != white || img.NRGBAAt(22, 22) == white {
+		t.Error("FAIL")
+	}
+	if n := count_pixels(img, white); n != 21 {
+		t.Errorf("FAIL %d", n)
+	}
+
+	// points off the image are clipped
+	pen.Point(img, vec.V2i{-100, 100})
+	pen.Polyline(img, []vec.V2i{{0, 0}, {39, 39}, {0, 39}})
+}
+
+func Test_Depth_Buffer(t *testing.T) {
+	d := New_Depth_Buffer(4, 4)
+	if d.Get(3, 3) != 1 || !d.Test(1, 1, 0.5) || d.Get(1, 1) != 0.5 {
+		t.Error("FAIL")
+	}
+	if d.Test(1, 1, 0.5) || d.Test(1, 1, 0.7) || !d.Test(1, 1, 0.2) {
+		t.Error("FAIL")
+	}
+	if d.Test(-1, 0, 0) || d.Test(0, 4, 0) {
+		t.Error("FAIL")
+	}
+
+	// test functions
+	tests := []struct {
+		f    Depth_Func
+		pass [3]bool // for z less than, equal to and greater than the stored depth
+	}{
+		{Depth_Less, [3]bool{true, false, false}},
+		{Depth_LEqual, [3]bool{true, true, false}},
+		{Depth_Greater, [3]bool{false, false, true}},
+		{Depth_Always, [3]bool{true, true, true}},
+		{Depth_Never, [3]bool{false, false, false}},
+	}
+	for _, x := range tests {
+		d.Func = x.f
+		d.Write = false
+		for i, z := range []float32{0.25, 0.5, 0.75} {
+			d.Set(0, 0, 0.5)
+			if d.Test(0, 0, z) != x.pass[i] || d.Get(0, 0) != 0.5 {
+				t.Errorf("FAIL %d %f", x.f, z)
+			}
+		}
+	}
+
+	// clear value
+	d.Clear_Value = 0
+	d.Clear()
+	if d.Get(1, 1) != 0 || d.Get(2, 3) != 0 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Depth_Triangle(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	d := New_Depth_Buffer(20, 20)
+
+	// a far triangle drawn over a near one
+	Depth_Triangle(img, d, [3]vec.V3{{0, 0, 0.2}, {20, 0, 0.2}, {0, 20, 0.2}}, red)
+	Depth_Triangle(img, d, [3]vec.V3{{0, 0, 0.8}, {0, 20, 0.8}, {20, 20, 0.8}}, blue)
+	if img.NRGBAAt(2, 5) != red || img.NRGBAAt(5, 15) != blue {
+		t.Error("FAIL")
+	}
+	if abs(d.Get(2, 5)-0.2) > 1e-6 || abs(d.Get(5, 15)-0.8) > 1e-6 {
+		t.Error("FAIL")
+	}
+
+	// depth is interpolated across a sloped triangle
+	d.Clear()
+	Depth_Triangle(img, d, [3]vec.V3{{0, 0, 0}, {20, 0, 1}, {0, 20, 0}}, red)
+	if z := d.Get(10, 2); z < 0.5 || z > 0.55 {
+		t.Errorf("FAIL %f", z)
+	}
+}
+
+// return the hit count for each pixel of a set of triangles
+func triangle_hits(tris [][3]vec.V2, r image.Rectangle) map[vec.V2i]int {
+	hits := make(map[vec.V2i]int)
+	for _, p := range tris {
+		Triangle(p, r, func(x, y int, b vec.V3) {
+			hits[vec.V2i{x, y}]++
+		})
+	}
+	return hits
+}
+
+// return a jittered grid of triangles covering the square [0, n*k]
+func grid_triangles(n int, k float32, jitter func() float32) [][3]vec.V2 {
+	v := make([][]vec.V2, n+1)
+	for j := 0; j <= n; j++ {
+		v[j] = make([]vec.V2, n+1)
+		for i := 0; i <= n; i++ {
+			p := vec.V2{float32(i) * k, float32(j) * k}
+			// keep the boundary straight
+			if i != 0 && i != n {
+				p[0] += jitter()
+			}
+			if j != 0 && j != n {
+				p[1] += jitter()
+			}
+			v[j][i] = p
+		}
+	}
+	var tris [][3]vec.V2
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			a, b, c, d := v[j][i], v[j][i+1], v[j+1][i+1], v[j+1][i]
+			if (i+j)%2 == 0 {
+				tris = append(tris, [3]vec.V2{a, b, c}, [3]vec.V2{a, c, d})
+			} else {
+				// clockwise
+				tris = append(tris, [3]vec.V2{b, a, d}, [3]vec.V2{b, d, c})
+			}
+		}
+	}
+	return tris
+}
+
+func Test_Fill_Rule(t *testing.T) {
+	r := image.Rect(0, 0, 64, 64)
+	jitters := map[string]func() float32{
+		// vertices on pixel centers and edges (ties on every shared edge)
+		"half": func() float32 { return float32(rand.Intn(9)-4) * 0.5 },
+		// sub-pixel positions
+		"fine": func() float32 { return float32(rand.Intn(1281)-640) / 256 },
+	}
+	for name, jitter := range jitters {
+		for k := 0; k < 20; k++ {
+			hits := triangle_hits(grid_triangles(8, 8, jitter), r)
+			if len(hits) != 64*64 {
+				t.Fatalf("FAIL %s: %d pixels covered", name, len(hits))
+			}
+			for p, n := range hits {
+				if n != 1 {
+					t.Fatalf("FAIL %s: pixel %v hit %d times", name, p, n)
+				}
+			}
+		}
+	}
+
+	// a fan around a vertex on a pixel center
+	c := vec.V2{10.5, 10.5}
+	var tris [][3]vec.V2
+	ring := []vec.V2{{2.5, 2.5}, {18.5, 2.5}, {18.5, 18.5}, {2.5, 18.5}}
+	for i := range ring {
+		tris = append(tris, [3]vec.V2{c, ring[i], ring[(i+1)%len(ring)]})
+	}
+	hits := triangle_hits(tris, r)
+	for p, n := range hits {
+		if n != 1 {
+			t.Fatalf("FAIL pixel %v hit %d times", p, n)
+		}
+	}
+	// half open square: [2.5, 18.5) for a top-left rule with y up
+	if len(hits) != 16*16 || hits[vec.V2i{2, 3}] != 1 || hits[vec.V2i{18, 3}] != 0 {
+		t.Errorf("FAIL %d", len(hits))
+	}
+}
+
+func Test_Degenerate_Triangles(t *testing.T) {
+	r := image.Rect(0, 0, 16, 16)
+	tris := [][3]vec.V2{
+		// collinear
+		{{1, 1}, {5, 5}, {9, 9}},
+		{{1.5, 1.5}, {1.5, 1.5}, {8.5, 1.5}},
+		// a point
+		{{3.5, 3.5}, {3.5, 3.5}, {3.5, 3.5}},
+		// sub-pixel, not covering a pixel center
+		{{4.1, 4.1}, {4.4, 4.1}, {4.1, 4.4}},
+		// off the clip rectangle
+		{{-10, -10}, {-2, -10}, {-10, -2}},
+		{{20, 20}, {30, 20}, {20, 30}},
+	}
+	if hits := triangle_hits(tris, r); len(hits) != 0 {
+		t.Errorf("FAIL %v", hits)
+	}
+	// a sub-pixel triangle covering a pixel center
+	if hits := triangle_hits([][3]vec.V2{{{4.4, 4.4}, {4.8, 4.4}, {4.4, 4.8}}}, r); len(hits) != 1 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Barycentric(t *testing.T) {
+	p := [3]vec.V2{{0, 0}, {0, 10}, {10, 0}}
+	Triangle(p, image.Rect(0, 0, 10, 10), func(x, y int, b vec.V3) {
+		c := vec.V2{float32(x) + 0.5, float32(y) + 0.5}
+		q := p[0].Scale(b[0]).Add(p[1].Scale(b[1])).Add(p[2].Scale(b[2]))
+		if !q.Equal_Eps(c, 1e-5) || b[0]+b[1]+b[2] < 0.99999 || b[0]+b[1]+b[2] > 1.00001 {
+			t.Errorf("FAIL %d %d %v", x, y, b)
+		}
+	})
+}
+
+// a test shader with clip space vertices and a color varying
+type test_shader struct {
+	q       []Qualifier // default smooth
+	pos     [][3]vec.V4
+	color   [][3]vec.V4
+	discard func(f *Fragment) bool
+}
+
+func (s *test_shader) Varyings() []Qualifier {
+	if s.q == nil {
+		return []Qualifier{Smooth, Smooth, Smooth, Smooth}
+	}
+	return s.q
+}
+
+func (s *test_shader) Vertex(i, k int) Vertex {
+	v := Vertex{Position: s.pos[i][k]}
+	copy(v.Varyings[:], s.color[i][k][:])
+	return v
+}
+
+func (s *test_shader) Fragment(f *Fragment) (vec.V4, bool) {
+	if s.discard != nil && s.discard(f) {
+		return vec.V4{}, false
+	}
+	return vec.V4{f.Varyings[0], f.Varyings[1], f.Varyings[2], f.Varyings[3]}, true
+}
+
+func Test_Pipeline(t *testing.T) {
+	red := vec.V4{1, 0, 0, 1}
+	blue := vec.V4{0, 0, 1, 1}
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	p := Pipeline{
+		Target: Image_Target{Img: img},
+		Depth:  New_Depth_Buffer(16, 16),
+		Cull:   Cull_Back,
+	}
+
+	// a full screen quad, then a nearer one with a discarded corner
+	quad := func(z float32) [][3]vec.V4 {
+		return [][3]vec.V4{
+			{{-1, -1, z, 1}, {1, -1, z, 1}, {1, 1, z, 1}},
+			{{-1, -1, z, 1}, {1, 1, z, 1}, {-1, 1, z, 1}},
+		}
+	}
+	s := &test_shader{pos: quad(0.5), color: [][3]vec.V4{{red, red, red}, {red, red, red}}}
+	p.Draw(s, 2)
+	if count_pixels(img, To_NRGBA(red)) != 256 {
+		t.Error("FAIL")
+	}
+	s = &test_shader{
+		pos:     quad(0),
+		color:   [][3]vec.V4{{blue, blue, blue}, {blue, blue, blue}},
+		discard: func(f *Fragment) bool { return f.X < 4 && f.Y < 4 },
+	}
+	p.Draw(s, 2)
+	if img.NRGBAAt(1, 1) != To_NRGBA(red) || img.NRGBAAt(8, 8) != To_NRGBA(blue) {
+		t.Error("FAIL")
+	}
+	if abs(p.Depth.Get(1, 1)-0.75) > 1e-6 || abs(p.Depth.Get(8, 8)-0.5) > 1e-6 {
+		t.Error("FAIL")
+	}
+
+	// back faces are culled
+	s.pos = [][3]vec.V4{{{-1, -1, -0.5, 1}, {1, 1, -0.5, 1}, {1, -1, -0.5, 1}}}
+	s.discard = nil
+	s.color = [][3]vec.V4{{red, red, red}}
+	p.Draw(s, 1)
+	if img.NRGBAAt(12, 4) != To_NRGBA(blue) {
+		t.Error("FAIL")
+	}
+
+	// perspective correct varyings: u is linear in clip space, not in screen space
+	w := [3]float32{1, 4, 1}
+	s.pos = [][3]vec.V4{{{-w[0], -w[0], 0, w[0]}, {w[1], -w[1], 0, w[1]}, {-w[2], w[2], 0, w[2]}}}
+	s.color = [][3]vec.V4{{{0, 0, 0, 1}, {1, 0, 0, 1}, {0, 0, 0, 1}}}
+	s.discard = func(f *Fragment) bool {
+		// screen space weight of vertex 1 and its perspective correct value
+		b1 := (float32(f.X) + 0.5) / 16
+		u := (b1 / w[1]) / ((1-b1)/w[0] + b1/w[1])
+		if f.Y == 0 && abs(f.Varyings[0]-u) > 1e-4 {
+			t.Errorf("FAIL %d %f %f", f.X, f.Varyings[0], u)
+		}
+		return false
+	}
+	p.Depth.Clear()
+	p.Draw(s, 1)
+}
+
+func Test_Clip(t *testing.T) {
+	var c Clipper
+	q := []Qualifier{Smooth}
+	vertex := func(x, y, z, w, u float32) Vertex {
+		v := Vertex{Position: vec.V4{x, y, z, w}}
+		v.Varyings[0] = u
+		return v
+	}
+
+	// inside
+	v := [3]Vertex{vertex(0, 0, 0, 1, 0), vertex(0.5, 0, 0, 1, 0), vertex(0, 0.5, 0, 1, 0)}
+	if len(c.Triangle(&v, q)) != 3 {
+		t.Error("FAIL")
+	}
+	// outside
+	v = [3]Vertex{vertex(2, 0, 0, 1, 0), vertex(3, 0, 0, 1, 0), vertex(2, 0.5, 0, 1, 0)}
+	if c.Triangle(&v, q) != nil {
+		t.Error("FAIL")
+	}
+
+	// one vertex across the right plane: a quad with interpolated varyings
+	v = [3]Vertex{vertex(0, 0, 0, 1, 0), vertex(2, 0, 0, 1, 1), vertex(0, 0.5, 0, 1, 0)}
+	poly := c.Triangle(&v, q)
+	if len(poly) != 4 {
+		t.Fatalf("FAIL %d", len(poly))
+	}
+	for _, p := range poly {
+		if p.Position[0] > p.Position[3] || abs(p.Varyings[0]-p.Position[0]/2) > 1e-6 {
+			t.Errorf("FAIL %v", p.Position)
+		}
+	}
+
+	// adjacent triangles clip their shared edge to the same point
+	shared := 0
+	for i := 0; i < 100; i++ {
+		a := vertex(0.1*float32(i%7)-0.3, 0.01*float32(i), 0.3, 1, 0)
+		b := vertex(1.3+0.037*float32(i), 0.11-0.013*float32(i), -0.2, 1.1, 1)
+		v0 := [3]Vertex{a, b, vertex(-0.5, 0.9, 0, 1, 0)}
+		v1 := [3]Vertex{b, a, vertex(-0.5, -0.9, 0, 1, 0)}
+		p0 := append([]Vertex(nil), c.Triangle(&v0, q)...)
+		p1 := c.Triangle(&v1, q)
+		for _, x := range p0 {
+			for _, y := range p1 {
+				if x.Position == y.Position && x.Position != a.Position {
+					shared++
+				}
+			}
+		}
+	}
+	if shared != 100 {
+		t.Errorf("FAIL %d", shared)
+	}
+
+	// a guard band only clips the near and far planes
+	c.Guard_Band = 4
+	if len(c.Triangle(&v, q)) != 3 {
+		t.Error("FAIL")
+	}
+	v = [3]Vertex{vertex(0, 0, -2, 1, 0), vertex(2, 0, 0, 1, 1), vertex(0, 0.5, 0, 1, 0)}
+	poly = c.Triangle(&v, q)
+	if len(poly) != 4 {
+		t.Fatalf("FAIL %d", len(poly))
+	}
+	for _, p := range poly {
+		if p.Position[2] < -p.Position[3] {
+			t.Errorf("FAIL %v", p.Position)
+		}
+	}
+	c.Guard_Band = 0
+
+	// lines
+	a, b := vertex(-2, 0, 0, 1, 0), vertex(2, 0, 0, 1, 1)
+	a, b, ok := c.Line(&a, &b, q)
+	if !ok || a.Position[0] != -1 || b.Position[0] != 1 || a.Varyings[0] != 0.25 || b.Varyings[0] != 0.75 {
+		t.Error("FAIL")
+	}
+	a, b = vertex(-2, 2, 0, 1, 0), vertex(2, 2, 0, 1, 1)
+	if _, _, ok := c.Line(&a, &b, q); ok {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Pipeline_Clip(t *testing.T) {
+	red := vec.V4{1, 0, 0, 1}
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	p := Pipeline{Target: Image_Target{Img: img}, Depth: New_Depth_Buffer(16, 16)}
+
+	// a triangle crossing the near plane with a vertex behind the eye
+	s := &test_shader{
+		pos:   [][3]vec.V4{{{-1, -1, 0, 1}, {1, -1, 0, 1}, {0, 3, -3, -1}}},
+		color: [][3]vec.V4{{red, red, red}},
+	}
+	for _, guard := range []float32{0, 8} {
+		img = image.NewNRGBA(image.Rect(0, 0, 16, 16))
+		p.Target = Image_Target{Img: img}
+		p.Depth.Clear()
+		p.Guard_Band = guard
+		p.Draw(s, 1)
+		n := count_pixels(img, To_NRGBA(red))
+		if n == 0 || img.NRGBAAt(8, 0) != To_NRGBA(red) {
+			t.Errorf("FAIL %f %d", guard, n)
+		}
+	}
+
+	// the guard band is limited so the fixed point edge functions don't overflow
+	g := Max_Guard_Band(image.Rect(0, 0, 16, 16))
+	if g != 2*float32(max_window-16)/16 {
+		t.Errorf("FAIL %f", g)
+	}
+	p.Depth = nil
+	for _, x := range [][2]float32{{g, g}, {1e9, 1e6}} {
+		guard, l := x[0], x[1]
+		img = image.NewNRGBA(image.Rect(0, 0, 16, 16))
+		p.Target = Image_Target{Img: img}
+		p.Guard_Band = guard
+		// a quad covering the target with its corners at +/- l
+		s.pos = [][3]vec.V4{
+			{{-l, -l, 0, 1}, {l, -l, 0, 1}, {l, l, 0, 1}},
+			{{-l, -l, 0, 1}, {l, l, 0, 1}, {-l, l, 0, 1}},
+		}
+		s.color = [][3]vec.V4{{red, red, red}, {red, red, red}}
+		p.Draw(s, 2)
+		if n := count_pixels(img, To_NRGBA(red)); n != 256 {
+			t.Errorf("FAIL %f %d", guard, n)
+		}
+	}
+	p.Guard_Band = 0
+
+	// lines are clipped to the target
+	img = image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	p.Target = Image_Target{Img: img}
+	s.pos = [][3]vec.V4{{{-3, 0, 0, 1}, {3, 0, 0, 1}}}
+	p.Draw_Lines(s, 1)
+	if count_pixels(img, To_NRGBA(red)) != 16 {
+		t.Errorf("FAIL %d", count_pixels(img, To_NRGBA(red)))
+	}
+}
+
+func Test_Qualifiers(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	p := Pipeline{Target: Image_Target{Img: img}}
+
+	// u smooth, u no perspective, vertex id flat
+	w := [3]float32{1, 4, 1}
+	s := &test_shader{
+		q:     []Qualifier{Smooth, No_Perspective, Flat},
+		pos:   [][3]vec.V4{{{-w[0], -w[0], 0, w[0]}, {w[1], -w[1], 0, w[1]}, {-w[2], w[2], 0, w[2]}}},
+		color: [][3]vec.V4{{{0, 0, 1}, {1, 1, 2}, {0, 0, 3}}},
+	}
+	for _, provoking := range []Provoking{Provoking_Last, Provoking_First} {
+		p.Provoking = provoking
+		n := 0
+		s.discard = func(f *Fragment) bool {
+			b1 := (float32(f.X) + 0.5) / 16
+			u := (b1 / w[1]) / ((1-b1)/w[0] + b1/w[1])
+			id := float32(3)
+			if provoking == Provoking_First {
+				id = 1
+			}
+			if f.Y == 0 && (abs(f.Varyings[0]-u) > 1e-4 || abs(f.Varyings[1]-b1) > 1e-5) {
+				t.Errorf("FAIL %d %v", f.X, f.Varyings[:2])
+			}
+			if f.Varyings[2] != id {
+				t.Errorf("FAIL %f", f.Varyings[2])
+			}
+			// derivatives
+			u1 := ((b1 + 1.0/16) / w[1]) / ((1-b1-1.0/16)/w[0] + (b1+1.0/16)/w[1])
+			if f.Y == 0 && (abs(f.Dx(0)-(u1-u)) > 1e-4 || abs(f.Dx(1)-1.0/16) > 1e-5 || abs(f.Dy(1)) > 1e-5 || f.Dx(2) != 0) {
+				t.Errorf("FAIL %d %f %f %f", f.X, f.Dx(0), f.Dx(1), f.Dy(1))
+			}
+			n++
+			return true
+		}
+		p.Draw(s, 1)
+		if n == 0 {
+			t.Error("FAIL")
+		}
+	}
+
+	// clipped vertices keep screen space no perspective values and flat values
+	s.pos = [][3]vec.V4{{{-1, -1, 0, 1}, {3, -1, 0, 1}, {-1, 1, 0, 1}}}
+	s.discard = func(f *Fragment) bool {
+		b1 := (float32(f.X) + 0.5) / 16 / 2
+		if f.Y == 0 && abs(f.Varyings[1]-b1) > 1e-5 {
+			t.Errorf("FAIL %d %v", f.X, f.Varyings[1])
+		}
+		if f.Varyings[2] != 1 {
+			t.Errorf("FAIL %f", f.Varyings[2])
+		}
+		return true
+	}
+	p.Draw(s, 1)
+}
+
+func Test_Tiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	r := func(lo, hi float32) float32 {
+		return lo + (hi-lo)*rng.Float32()
+	}
+	// random triangles crossing the view frustum, with repeats for depth ties
+	s := &test_shader{
+		q: []Qualifier{Smooth, No_Perspective, Flat, Smooth},
+		discard: func(f *Fragment) bool {
+			return (f.X*7+f.Y*13)%29 == 0
+		},
+	}
+	for i := 0; i < 400; i++ {
+		var pos, color [3]vec.V4
+		for k := range pos {
+			w := r(0.5, 2)
+			pos[k] = vec.V4{r(-1.3, 1.3) * w, r(-1.3, 1.3) * w, r(-1.1, 1.1) * w, w}
+			color[k] = vec.V4{r(0, 1), r(0, 1), r(0, 1), 1}
+		}
+		s.pos = append(s.pos, pos)
+		s.color = append(s.color, color)
+		if i%10 == 0 {
+			color[0][0] = 1 - color[0][0]
+			s.pos = append(s.pos, pos)
+			s.color = append(s.color, color)
+		}
+	}
+
+	// draw with a depth buffer (origin at 0,0) and without (offset bounds)
+	draw := func(bounds image.Rectangle, depth bool, workers, tile_size int) (*image.NRGBA, *Depth_Buffer) {
+		img := image.NewNRGBA(bounds)
+		p := Pipeline{
+			Target:     Image_Target{Img: img},
+			Guard_Band: 2,
+			Workers:    workers,
+			Tile_Size:  tile_size,
+		}
+		if depth {
+			p.Depth = New_Depth_Buffer(bounds.Dx(), bounds.Dy())
+		}
+		p.Draw(s, len(s.pos))
+		return img, p.Depth
+	}
+	for _, test := range []struct {
+		bounds image.Rectangle
+		depth  bool
+	}{
+		{image.Rect(0, 0, 203, 150), true},
+		{image.Rect(-30, 17, 100, 90), false},
+	} {
+		img0, depth0 := draw(test.bounds, test.depth, 0, 0)
+		if img0.Opaque() || string(img0.Pix) == string(make([]uint8, len(img0.Pix))) {
+			t.Fatal("FAIL")
+		}
+		for _, workers := range []int{1, 3, 8} {
+			for _, size := range []int{0, 7, 64} {
+				img, depth := draw(test.bounds, test.depth, workers, size)
+				if string(img.Pix) != string(img0.Pix) {
+					t.Errorf("FAIL %v workers %d tile size %d", test.bounds, workers, size)
+				}
+				if depth0 != nil {
+					for y := 0; y < depth0.Height; y++ {
+						for x := 0; x < depth0.Width; x++ {
+							if depth.Get(x, y) != depth0.Get(x, y) {
+								t.Fatalf("FAIL depth %d %d workers %d tile size %d", x, y, workers, size)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
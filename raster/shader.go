@@ -0,0 +1,165 @@
+//-----------------------------------------------------------------------------
+/*
+
+Programmable Shaders
+
+A shader has a vertex stage and a fragment stage.
+
+The vertex stage is called for each vertex of each triangle. It returns the
+clip space position of the vertex and a set of varyings (e.g. normals, uvs,
+colors) to be interpolated across the triangle.
+
+The fragment stage is called for each covered pixel that passes the depth
+test. It gets the interpolated varyings and returns a color, or discards the
+fragment. The change in a varying to the next pixel in x or y is available
+for e.g. texture level of detail.
+
+Each varying has an interpolation qualifier:
+
+Smooth - interpolated with perspective correction (the default).
+No_Perspective - interpolated linearly in screen space.
+Flat - the value from the provoking vertex for the whole primitive.
+
+Colors are RGBA with components in [0,1].
+
+A shader may also write several outputs per fragment (e.g. a color, a normal
+and an object id) to a target with multiple outputs.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// maximum number of varyings per vertex
+const Max_Varyings = 16
+
+// Qualifier selects how a varying is interpolated
+type Qualifier int
+
+const (
+	Smooth         Qualifier = iota // perspective correct
+	No_Perspective                  // linear in screen space
+	Flat                            // constant from the provoking vertex
+)
+
+// Provoking selects the vertex that supplies flat varyings
+type Provoking int
+
+const (
+	Provoking_Last  Provoking = iota // the last vertex of the primitive (OpenGL default)
+	Provoking_First                  // the first vertex of the primitive
+)
+
+// Vertex is the output of the vertex stage
+type Vertex struct {
+	Position vec.V4                // clip space position
+	Varyings [Max_Varyings]float32 // values interpolated across the triangle
+}
+
+// Fragment is the input to the fragment stage
+type Fragment struct {
+	X, Y     int                   // pixel position
+	Z        float32               // window depth
+	Front    bool                  // the triangle is front facing
+	Tri      int                   // triangle index
+	Varyings [Max_Varyings]float32 // interpolated varyings
+	// for derivatives
+	q        []Qualifier
+	b        vec.V3 // screen space weights
+	dbx, dby vec.V3 // change in weights per pixel in x and y
+	w        [3]*window_vertex
+}
+
+// return varying j at screen space weights b
+func (f *Fragment) varying(j int, b vec.V3) float32 {
+	v0, v1, v2 := &f.w[0].v.Varyings, &f.w[1].v.Varyings, &f.w[2].v.Varyings
+	switch f.q[j] {
+	case Smooth:
+		b = b.Mul(vec.V3{f.w[0].inv_w, f.w[1].inv_w, f.w[2].inv_w})
+		b = b.Scale(1 / (b[0] + b[1] + b[2]))
+	case Flat:
+		return v0[j]
+	}
+	return b[0]*v0[j] + b[1]*v1[j] + b[2]*v2[j]
+}
+
+// Dx returns the change in varying j to the next pixel in x
+func (f *Fragment) Dx(j int) float32 {
+	return f.varying(j, f.b.Add(f.dbx)) - f.Varyings[j]
+}
+
+// Dy returns the change in varying j to the next pixel in y
+func (f *Fragment) Dy(j int) float32 {
+	return f.varying(j, f.b.Add(f.dby)) - f.Varyings[j]
+}
+
+// Shader is a programmable vertex and fragment stage
+type Shader interface {
+	// Varyings returns the qualifiers of the varyings written by the vertex stage
+	Varyings() []Qualifier
+	// Vertex returns vertex k (0,1,2) of triangle i
+	Vertex(i, k int) Vertex
+	// Fragment returns the fragment color, or false to discard it
+	Fragment(f *Fragment) (vec.V4, bool)
+}
+
+// MRT_Shader is a shader with a fragment stage that writes several outputs
+type MRT_Shader interface {
+	Shader
+	// Fragment_MRT sets the fragment outputs, or returns false to discard it
+	Fragment_MRT(f *Fragment, out []vec.V4) bool
+}
+
+//-----------------------------------------------------------------------------
+
+// Target is something the pipeline can draw colors into
+type Target interface {
+	Bounds() image.Rectangle
+	Set(x, y int, c vec.V4)
+}
+
+// MRT_Target is a target with several outputs (multiple render targets)
+type MRT_Target interface {
+	Target
+	// Outputs returns the number of outputs
+	Outputs() int
+	// Set_Output sets output i at x, y
+	Set_Output(i, x, y int, c vec.V4)
+}
+
+// Image_Target draws into an NRGBA image
+type Image_Target struct {
+	Img *image.NRGBA
+}
+
+// Bounds returns the image bounds
+func (t Image_Target) Bounds() image.Rectangle {
+	return t.Img.Bounds()
+}
+
+// Set sets an image pixel to a color
+func (t Image_Target) Set(x, y int, c vec.V4) {
+	t.Img.SetNRGBA(x, y, To_NRGBA(c))
+}
+
+// To_NRGBA converts a color to 8 bit components
+func To_NRGBA(c vec.V4) color.NRGBA {
+	c = c.Clamp(vec.V4{0, 0, 0, 0}, vec.V4{1, 1, 1, 1})
+	return color.NRGBA{
+		uint8(c[0]*255 + 0.5),
+		uint8(c[1]*255 + 0.5),
+		uint8(c[2]*255 + 0.5),
+		uint8(c[3]*255 + 0.5),
+	}
+}
+
+//-----------------------------------------------------------------------------
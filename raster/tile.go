@@ -0,0 +1,128 @@
+//-----------------------------------------------------------------------------
+/*
+
+Tiled Parallel Rasterization
+
+The target is split into square screen tiles. All triangles of a draw are
+set up first (vertex stage, clipping, window mapping and culling, in draw
+order) and binned to the tiles their bounding box overlaps. A pool of
+workers then rasterizes the tiles, each tile by one worker with the tile
+bins in draw order.
+
+Every pixel is in one tile and sees the same fragments in the same order as
+with serial rasterization, and the edge function values at a pixel do not
+depend on the clip rectangle. So the output is the same for any number of
+workers and any scheduling.
+
+The vertex stage runs on the calling goroutine. The fragment stage runs on
+the workers, so Fragment (or Fragment_MRT) must be safe for concurrent use,
+and the target must allow concurrent Set calls for different pixels.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"sync"
+	"sync/atomic"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// default tile width and height in pixels
+const default_tile_size = 64
+
+// a triangle after setup, ready to rasterize
+type tile_triangle struct {
+	v     [3]Vertex
+	w     [3]window_vertex
+	front bool
+	tri   int // primitive index
+}
+
+// return the tile size
+func (p *Pipeline) tile_size() int {
+	if p.Tile_Size > 0 {
+		return p.Tile_Size
+	}
+	return default_tile_size
+}
+
+// set up n triangles, bin them per tile and rasterize the tiles in parallel
+func (p *Pipeline) draw_tiles(s Shader, q []Qualifier, n int) {
+
+	// set up the triangles in draw order
+	var tris []tile_triangle
+	p.setup_triangles(s, q, n, func(i int, front bool, w [3]*window_vertex) {
+		t := tile_triangle{front: front, tri: i}
+		for k := range w {
+			t.v[k] = *w[k].v
+			t.w[k] = *w[k]
+		}
+		tris = append(tris, t)
+	})
+	if len(tris) == 0 {
+		return
+	}
+
+	// bin the triangles per tile
+	r := p.Target.Bounds()
+	size := p.tile_size()
+	nx := (r.Dx() + size - 1) / size
+	ny := (r.Dy() + size - 1) / size
+	bins := make([][]int32, nx*ny)
+	for i := range tris {
+		t := &tris[i]
+		for k := range t.w {
+			t.w[k].v = &t.v[k]
+		}
+		// pixels that may be covered
+		lo := t.w[0].xy.Min(t.w[1].xy).Min(t.w[2].xy)
+		hi := t.w[0].xy.Max(t.w[1].xy).Max(t.w[2].xy)
+		x0 := max(int(floor(lo[0]))-r.Min.X, 0) / size
+		y0 := max(int(floor(lo[1]))-r.Min.Y, 0) / size
+		x1 := min(int(floor(hi[0]))-r.Min.X, r.Dx()-1) / size
+		y1 := min(int(floor(hi[1]))-r.Min.Y, r.Dy()-1) / size
+		for ty := y0; ty <= y1; ty++ {
+			for tx := x0; tx <= x1; tx++ {
+				bins[ty*nx+tx] = append(bins[ty*nx+tx], int32(i))
+			}
+		}
+	}
+
+	// rasterize the tiles
+	workers := min(p.Workers, len(bins))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			// per worker shader outputs
+			out := make([]vec.V4, len(p.out))
+			for {
+				k := int(next.Add(1) - 1)
+				if k >= len(bins) {
+					return
+				}
+				if len(bins[k]) == 0 {
+					continue
+				}
+				tx, ty := k%nx, k/nx
+				clip := image.Rect(tx*size, ty*size, (tx+1)*size, (ty+1)*size).Add(r.Min).Intersect(r)
+				for _, i := range bins[k] {
+					t := &tris[i]
+					f := Fragment{Front: t.front, Tri: t.tri}
+					p.triangle(s, &f, q, [3]*window_vertex{&t.w[0], &t.w[1], &t.w[2]}, clip, out)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+//-----------------------------------------------------------------------------
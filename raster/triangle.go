@@ -0,0 +1,42 @@
+//-----------------------------------------------------------------------------
+/*
+
+Filled and Depth Tested Triangles
+
+The triangle vertices are in screen space: x and y in pixels and z as a
+depth. Each covered pixel gets a depth interpolated from the vertex depths,
+and is drawn if it passes the depth test.
+
+*/
+//-----------------------------------------------------------------------------
+
+package raster
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Fill_Triangle draws a triangle with a single color
+func Fill_Triangle(img *image.NRGBA, p [3]vec.V2, color color.NRGBA) {
+	Triangle(p, img.Bounds(), func(x, y int, b vec.V3) {
+		img.SetNRGBA(x, y, color)
+	})
+}
+
+// Depth_Triangle draws a triangle with per-pixel depth testing
+func Depth_Triangle(img *image.NRGBA, depth *Depth_Buffer, p [3]vec.V3, color color.NRGBA) {
+	z := vec.V3{p[0][2], p[1][2], p[2][2]}
+	xy := [3]vec.V2{p[0].ToV2(), p[1].ToV2(), p[2].ToV2()}
+	Triangle(xy, img.Bounds(), func(x, y int, b vec.V3) {
+		if depth.Test(x, y, b.Dot(z)) {
+			img.SetNRGBA(x, y, color)
+		}
+	})
+}
+
+//-----------------------------------------------------------------------------
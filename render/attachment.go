@@ -0,0 +1,226 @@
+//-----------------------------------------------------------------------------
+/*
+
+Framebuffer Attachments
+
+An attachment is a per-pixel buffer of a single format. Values are set and
+read as RGBA vectors in window coordinates (y-axis up, as drawn by the
+pipeline). Rows are stored top down in image order, so exporting an
+attachment as an image needs no flip.
+
+RGBA8 - 8 bit color, components in [0,1] are clamped and rounded.
+RGBA32F - float32 color, values are stored as is.
+Depth32F - float32 depth in the red component (shared with the pipeline).
+Stencil8 - 8 bit stencil value in the red component.
+ID32 - uint32 id. The red component carries the id bits (see ID_Value).
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/deadsy/sw_render/raster"
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Format is an attachment pixel format
+type Format int
+
+const (
+	RGBA8 Format = iota
+	RGBA32F
+	Depth32F
+	Stencil8
+	ID32
+)
+
+func (f Format) String() string {
+	switch f {
+	case RGBA8:
+		return "rgba8"
+	case RGBA32F:
+		return "rgba32f"
+	case Depth32F:
+		return "depth32f"
+	case Stencil8:
+		return "stencil8"
+	case ID32:
+		return "id32"
+	}
+	return "unknown"
+}
+
+// is the format a color output for fragment shaders?
+func (f Format) output() bool {
+	return f == RGBA8 || f == RGBA32F || f == ID32
+}
+
+// ID_Value returns a fragment output value that writes an id to an ID32 attachment
+func ID_Value(id uint32) vec.V4 {
+	return vec.V4{math.Float32frombits(id), 0, 0, 0}
+}
+
+//-----------------------------------------------------------------------------
+
+// Attachment is a framebuffer buffer with a pixel format
+type Attachment struct {
+	Name        string
+	Format      Format
+	Clear_Value vec.V4 // color, or depth/stencil/id value in the red component
+	fb          *Framebuffer
+	rgba8       []uint8
+	rgba32f     []vec.V4
+	stencil     []uint8
+	id          []uint32
+	depth       *raster.Depth_Buffer // rows are in window order
+}
+
+// allocate the buffer for the framebuffer size
+func (a *Attachment) alloc() {
+	w, h := a.fb.Width, a.fb.Height
+	switch a.Format {
+	case RGBA8:
+		a.rgba8 = make([]uint8, 4*w*h)
+	case RGBA32F:
+		a.rgba32f = make([]vec.V4, w*h)
+	case Depth32F:
+		if a.depth == nil {
+			a.depth = raster.New_Depth_Buffer(w, h)
+		} else {
+			a.depth.Resize(w, h)
+		}
+	case Stencil8:
+		a.stencil = make([]uint8, w*h)
+	case ID32:
+		a.id = make([]uint32, w*h)
+	}
+	a.Clear()
+}
+
+// return the buffer index for window coordinates
+func (a *Attachment) index(x, y int) int {
+	if a.fb.Origin == Bottom_Left {
+		y = a.fb.Height - 1 - y
+	}
+	return y*a.fb.Width + x
+}
+
+// Set sets the value at window coordinates x, y
+func (a *Attachment) Set(x, y int, c vec.V4) {
+	switch a.Format {
+	case RGBA8:
+		i := 4 * a.index(x, y)
+		n := raster.To_NRGBA(c)
+		a.rgba8[i+0] = n.R
+		a.rgba8[i+1] = n.G
+		a.rgba8[i+2] = n.B
+		a.rgba8[i+3] = n.A
+	case RGBA32F:
+		a.rgba32f[a.index(x, y)] = c
+	case Depth32F:
+		a.depth.Set(x, y, c[0])
+	case Stencil8:
+		a.stencil[a.index(x, y)] = uint8(min(max(c[0], 0), 255))
+	case ID32:
+		a.id[a.index(x, y)] = math.Float32bits(c[0])
+	}
+}
+
+// Get returns the value at window coordinates x, y
+func (a *Attachment) Get(x, y int) vec.V4 {
+	switch a.Format {
+	case RGBA8:
+		i := 4 * a.index(x, y)
+		p := a.rgba8[i : i+4]
+		return vec.V4{float32(p[0]), float32(p[1]), float32(p[2]), float32(p[3])}.Scale(1.0 / 255)
+	case RGBA32F:
+		return a.rgba32f[a.index(x, y)]
+	case Depth32F:
+		return vec.V4{a.depth.Get(x, y), 0, 0, 0}
+	case Stencil8:
+		return vec.V4{float32(a.stencil[a.index(x, y)]), 0, 0, 0}
+	}
+	return ID_Value(a.id[a.index(x, y)])
+}
+
+// ID returns the id at window coordinates x, y of an ID32 attachment
+func (a *Attachment) ID(x, y int) uint32 {
+	return a.id[a.index(x, y)]
+}
+
+// Clear sets all pixels to the clear value
+func (a *Attachment) Clear() {
+	switch a.Format {
+	case RGBA8:
+		n := raster.To_NRGBA(a.Clear_Value)
+		for i := 0; i < len(a.rgba8); i += 4 {
+			a.rgba8[i+0] = n.R
+			a.rgba8[i+1] = n.G
+			a.rgba8[i+2] = n.B
+			a.rgba8[i+3] = n.A
+		}
+	case RGBA32F:
+		for i := range a.rgba32f {
+			a.rgba32f[i] = a.Clear_Value
+		}
+	case Depth32F:
+		a.depth.Clear_Value = a.Clear_Value[0]
+		a.depth.Clear()
+	case Stencil8:
+		v := uint8(min(max(a.Clear_Value[0], 0), 255))
+		for i := range a.stencil {
+			a.stencil[i] = v
+		}
+	case ID32:
+		v := math.Float32bits(a.Clear_Value[0])
+		for i := range a.id {
+			a.id[i] = v
+		}
+	}
+}
+
+// Image returns the attachment as an image.
+// RGBA8 and Stencil8 images share the attachment memory.
+// RGBA32F colors are clamped to [0,1], depth maps [0,1] to grey levels
+// and the low 24 bits of ids are red, green and blue.
+func (a *Attachment) Image() image.Image {
+	w, h := a.fb.Width, a.fb.Height
+	r := image.Rect(0, 0, w, h)
+	switch a.Format {
+	case RGBA8:
+		return &image.NRGBA{Pix: a.rgba8, Stride: 4 * w, Rect: r}
+	case Stencil8:
+		return &image.Gray{Pix: a.stencil, Stride: w, Rect: r}
+	case RGBA32F:
+		img := image.NewNRGBA64(r)
+		for i, c := range a.rgba32f {
+			c = c.Clamp(vec.V4{0, 0, 0, 0}, vec.V4{1, 1, 1, 1}).Scale(0xffff)
+			img.SetNRGBA64(i%w, i/w, color.NRGBA64{uint16(c[0] + 0.5), uint16(c[1] + 0.5), uint16(c[2] + 0.5), uint16(c[3] + 0.5)})
+		}
+		return img
+	case Depth32F:
+		img := image.NewGray16(r)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				z := min(max(a.depth.Get(x, y), 0), 1)
+				i := a.index(x, y)
+				img.SetGray16(i%w, i/w, color.Gray16{uint16(z*0xffff + 0.5)})
+			}
+		}
+		return img
+	}
+	img := image.NewNRGBA(r)
+	for i, id := range a.id {
+		img.SetNRGBA(i%w, i/w, color.NRGBA{uint8(id >> 16), uint8(id >> 8), uint8(id), 255})
+	}
+	return img
+}
+
+//-----------------------------------------------------------------------------
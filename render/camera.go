@@ -0,0 +1,224 @@
+//-----------------------------------------------------------------------------
+/*
+
+Cameras
+
+A camera looks from an eye position towards a target position with an up
+direction. It has a perspective projection (vertical field of view) or an
+orthographic projection (view height in world units). A camera looking
+along its up direction (e.g. a top view) uses another up direction.
+
+Orbit rotates the eye about the target, Pan moves the eye and target across
+the view, and Dolly moves the eye towards or away from the target. Frame
+moves the camera along its current view direction so a bounding box fills
+the view.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+//-----------------------------------------------------------------------------
+
+// Projection is a camera projection type
+type Projection int
+
+const (
+	Perspective Projection = iota
+	Orthographic
+)
+
+func (p Projection) String() string {
+	switch p {
+	case Perspective:
+		return "perspective"
+	case Orthographic:
+		return "orthographic"
+	}
+	return "unknown"
+}
+
+// Camera is a view and projection
+type Camera struct {
+	Eye        vec.V3 // camera position
+	Target     vec.V3 // position the camera looks at
+	Up         vec.V3 // up direction
+	Projection Projection
+	Fov        float32 // vertical field of view in radians (perspective)
+	Height     float32 // view height in world units (orthographic)
+	Aspect     float32 // view width / height
+	Near, Far  float32 // clip plane distances from the eye
+}
+
+// New_Camera returns a perspective camera at z = 1 looking at the origin
+func New_Camera() *Camera {
+	return &Camera{
+		Eye:        vec.V3{0, 0, 1},
+		Up:         vec.V3{0, 1, 0},
+		Projection: Perspective,
+		Fov:        math.Pi / 4,
+		Height:     1,
+		Aspect:     1,
+		Near:       0.1,
+		Far:        100,
+	}
+}
+
+// Forward returns the unit view direction
+func (c *Camera) Forward() vec.V3 {
+	return c.Target.Sub(c.Eye).Normalize()
+}
+
+// View_Up returns the up direction of the view. Looking along the up
+// direction it is the world axis least aligned with the view direction.
+func (c *Camera) View_Up() vec.V3 {
+	f := c.Forward()
+	up := c.Up.Normalize()
+	if f.Cross(up).Length() > 1e-6 {
+		return up
+	}
+	up = vec.V3{0, 0, 1}
+	for _, axis := range []vec.V3{{1, 0, 0}, {0, 1, 0}} {
+		if abs(f.Dot(axis)) < abs(f.Dot(up)) {
+			up = axis
+		}
+	}
+	return up
+}
+
+// Right returns the unit direction to the right of the view
+func (c *Camera) Right() vec.V3 {
+	return c.Forward().Cross(c.View_Up()).Normalize()
+}
+
+// Distance returns the distance from the eye to the target
+func (c *Camera) Distance() float32 {
+	return c.Target.Sub(c.Eye).Length()
+}
+
+// View returns the world to view transform
+func (c *Camera) View() vec.M4 {
+	return vec.LookAt(c.Eye, c.Target, c.View_Up())
+}
+
+// Proj returns the view to clip transform
+func (c *Camera) Proj() vec.M4 {
+	if c.Projection == Orthographic {
+		h := c.Height / 2
+		w := h * c.Aspect
+		return vec.Orthographic(-w, w, -h, h, c.Near, c.Far)
+	}
+	return vec.Perspective(c.Fov, c.Aspect, c.Near, c.Far)
+}
+
+// View_Proj returns the world to clip transform
+func (c *Camera) View_Proj() vec.M4 {
+	return c.Proj().Mul(c.View())
+}
+
+//-----------------------------------------------------------------------------
+
+// minimum angle between the view direction and the up direction
+const min_pitch_angle = 1e-3
+
+// Orbit rotates the eye about the target by yaw radians about the up
+// direction and pitch radians about the right direction (positive pitch
+// raises the eye). The pitch stops short of looking along the up direction,
+// unless the eye is already there.
+func (c *Camera) Orbit(yaw, pitch float32) {
+	up := c.Up.Normalize()
+	yaw_rotate := vec.Axis_Angle(up, yaw)
+	ofs := yaw_rotate.Rotate(c.Eye.Sub(c.Target))
+	// angle from the up direction
+	a := float32(math.Acos(float64(clamp(ofs.Normalize().Dot(up), -1, 1))))
+	pitch = clamp(pitch, min(a-math.Pi+min_pitch_angle, 0), max(a-min_pitch_angle, 0))
+	right := up.Cross(ofs)
+	if right.Length() < 1e-6*ofs.Length() {
+		// the eye is above or below the target, yaw the view right direction
+		right = yaw_rotate.Rotate(c.Right())
+	}
+	right = right.Normalize()
+	ofs = vec.Axis_Angle(right, -pitch).Rotate(ofs)
+	c.Eye = c.Target.Add(ofs)
+}
+
+// Pan moves the eye and target by dx to the right and dy up in world units
+func (c *Camera) Pan(dx, dy float32) {
+	right := c.Right()
+	up := right.Cross(c.Forward())
+	d := right.Scale(dx).Add(up.Scale(dy))
+	c.Eye = c.Eye.Add(d)
+	c.Target = c.Target.Add(d)
+}
+
+// Dolly scales the eye to target distance by k (k < 1 moves closer).
+// An orthographic view height is scaled the same way.
+func (c *Camera) Dolly(k float32) {
+	c.Eye = c.Target.Add(c.Eye.Sub(c.Target).Scale(k))
+	if c.Projection == Orthographic {
+		c.Height *= k
+	}
+}
+
+// Frame looks at the center of a bounding box from the current view
+// direction, at a distance where the bounding sphere fills the view.
+// The near and far planes are set to enclose the sphere.
+func (c *Camera) Frame(lo, hi vec.V3) {
+	center := lo.Add(hi).Scale(0.5)
+	r := hi.Sub(lo).Length() / 2
+	if r == 0 {
+		r = 1
+	}
+	dir := c.Forward()
+	if dir.Length() == 0 {
+		dir = vec.V3{0, 0, -1}
+	}
+	// half angle of the narrower field of view
+	half := c.Fov / 2
+	if c.Aspect < 1 {
+		half = float32(math.Atan(math.Tan(float64(half)) * float64(c.Aspect)))
+	}
+	d := 2 * r
+	if c.Projection == Perspective {
+		d = r / float32(math.Sin(float64(half)))
+	}
+	c.Target = center
+	c.Eye = center.Sub(dir.Scale(d))
+	c.Height = 2 * r
+	if c.Aspect < 1 {
+		c.Height /= c.Aspect
+	}
+	c.Near = max(d-r, d/1000)
+	c.Far = d + r
+}
+
+// Frame_Object frames the bounding box of an object
+func (c *Camera) Frame_Object(obj *wavefront.Object) {
+	lo := vec.V3{obj.Min_V(0), obj.Min_V(1), obj.Min_V(2)}
+	hi := vec.V3{obj.Max_V(0), obj.Max_V(1), obj.Max_V(2)}
+	c.Frame(lo, hi)
+}
+
+//-----------------------------------------------------------------------------
+
+// return the absolute value of x
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// return x clamped to [lo, hi]
+func clamp(x, lo, hi float32) float32 {
+	return min(max(x, lo), hi)
+}
+
+//-----------------------------------------------------------------------------
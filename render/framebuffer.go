@@ -0,0 +1,163 @@
+//-----------------------------------------------------------------------------
+/*
+
+Framebuffers
+
+A framebuffer is a set of named attachments of the same size. It is a
+render target for the raster pipeline. The color attachments (RGBA8, RGBA32F
+and ID32) are the fragment shader outputs, in the order they were attached.
+The depth attachment is the depth buffer for the pipeline.
+
+The origin sets where window coordinate y = 0 is. With a bottom left origin
+the y-axis is up (OpenGL style), with a top left origin it is down (image
+style). Either way the exported images are the right way up.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/deadsy/sw_render/raster"
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Origin is the position of window coordinate y = 0
+type Origin int
+
+const (
+	Bottom_Left Origin = iota // y-axis up
+	Top_Left                  // y-axis down
+)
+
+// Framebuffer is a set of named attachments
+type Framebuffer struct {
+	Width, Height int
+	Origin        Origin
+	attachments   []*Attachment
+	outputs       []*Attachment // fragment shader outputs
+	depth         *Attachment
+}
+
+// New_Framebuffer returns a framebuffer with no attachments
+func New_Framebuffer(width, height int, origin Origin) *Framebuffer {
+	return &Framebuffer{
+		Width:  width,
+		Height: height,
+		Origin: origin,
+	}
+}
+
+// Attach adds a cleared attachment.
+// Color attachments are cleared to transparent black, depth to 1, stencil and ids to 0.
+func (fb *Framebuffer) Attach(name string, format Format) (*Attachment, error) {
+	if fb.Get(name) != nil {
+		return nil, fmt.Errorf("attachment \"%s\" already exists", name)
+	}
+	if format == Depth32F && fb.depth != nil {
+		return nil, fmt.Errorf("attachment \"%s\": only one depth attachment is allowed", name)
+	}
+	a := &Attachment{
+		Name:   name,
+		Format: format,
+		fb:     fb,
+	}
+	if format == Depth32F {
+		a.Clear_Value = vec.V4{1, 0, 0, 0}
+		fb.depth = a
+	}
+	a.alloc()
+	fb.attachments = append(fb.attachments, a)
+	if format.output() {
+		fb.outputs = append(fb.outputs, a)
+	}
+	return a, nil
+}
+
+// Get returns the named attachment (nil if none)
+func (fb *Framebuffer) Get(name string) *Attachment {
+	for _, a := range fb.attachments {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Attachments returns the attachments in the order they were attached
+func (fb *Framebuffer) Attachments() []*Attachment {
+	return fb.attachments
+}
+
+// Depth returns the depth buffer of the depth attachment (nil if none)
+func (fb *Framebuffer) Depth() *raster.Depth_Buffer {
+	if fb.depth == nil {
+		return nil
+	}
+	return fb.depth.depth
+}
+
+// Clear clears all attachments to their clear values
+func (fb *Framebuffer) Clear() {
+	for _, a := range fb.attachments {
+		a.Clear()
+	}
+}
+
+// Resize changes the framebuffer size. All attachments are cleared.
+func (fb *Framebuffer) Resize(width, height int) {
+	fb.Width = width
+	fb.Height = height
+	for _, a := range fb.attachments {
+		a.alloc()
+	}
+}
+
+// Image returns the named attachment as an image
+func (fb *Framebuffer) Image(name string) (image.Image, error) {
+	a := fb.Get(name)
+	if a == nil {
+		return nil, fmt.Errorf("no attachment \"%s\"", name)
+	}
+	return a.Image(), nil
+}
+
+// Pipeline returns a pipeline drawing into the framebuffer with its depth buffer
+func (fb *Framebuffer) Pipeline() *raster.Pipeline {
+	return &raster.Pipeline{
+		Target: fb,
+		Depth:  fb.Depth(),
+	}
+}
+
+//-----------------------------------------------------------------------------
+// raster.MRT_Target
+
+// Bounds returns the framebuffer bounds in window coordinates
+func (fb *Framebuffer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, fb.Width, fb.Height)
+}
+
+// Set sets the first output at x, y
+func (fb *Framebuffer) Set(x, y int, c vec.V4) {
+	if len(fb.outputs) != 0 {
+		fb.outputs[0].Set(x, y, c)
+	}
+}
+
+// Outputs returns the number of fragment shader outputs
+func (fb *Framebuffer) Outputs() int {
+	return len(fb.outputs)
+}
+
+// Set_Output sets output i at x, y
+func (fb *Framebuffer) Set_Output(i, x, y int, c vec.V4) {
+	fb.outputs[i].Set(x, y, c)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+//-----------------------------------------------------------------------------
+/*
+
+Render Modes
+
+A render mode selects the mesh shader used to draw a model.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/raster"
+)
+
+//-----------------------------------------------------------------------------
+
+// Mode is a render mode
+type Mode int
+
+const (
+	Wireframe Mode = iota
+	Flat
+	Gouraud
+	Phong
+	Textured
+	Depth
+	Normals
+)
+
+var mode_names = []string{"wireframe", "flat", "gouraud", "phong", "textured", "depth", "normals"}
+
+func (m Mode) String() string {
+	if m >= 0 && int(m) < len(mode_names) {
+		return mode_names[m]
+	}
+	return "unknown"
+}
+
+// Mode_Names returns the names of the render modes
+func Mode_Names() []string {
+	return mode_names
+}
+
+// Parse_Mode returns the render mode with a name
+func Parse_Mode(s string) (Mode, error) {
+	for i, name := range mode_names {
+		if s == name {
+			return Mode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode \"%s\" (%s)", s, strings.Join(mode_names, ", "))
+}
+
+//-----------------------------------------------------------------------------
+
+// New_Shader returns the shader for a render mode.
+// The lighting is used by the phong shader, the camera by the phong and depth shaders.
+func New_Shader(mode Mode, ms Mesh_Shader, lighting *light.Lighting, camera *Camera) (raster.Shader, error) {
+	switch mode {
+	case Wireframe:
+		return &Wireframe_Shader{Mesh_Shader: ms}, nil
+	case Flat:
+		return &Flat_Shader{Mesh_Shader: ms}, nil
+	case Gouraud:
+		return &Gouraud_Shader{Mesh_Shader: ms}, nil
+	case Phong:
+		return New_Phong_Shader(ms, lighting, camera.Eye), nil
+	case Textured:
+		return New_Textured_Shader(ms)
+	case Depth:
+		s := &Depth_Shader{Mesh_Shader: ms}
+		if camera.Projection == Perspective {
+			s.Near, s.Far = camera.Near, camera.Far
+		}
+		return s, nil
+	case Normals:
+		return &Normal_Shader{Mesh_Shader: ms}, nil
+	}
+	return nil, fmt.Errorf("unknown mode %d", mode)
+}
+
+// Draw_Mesh draws the mesh of a mesh shader, as lines for the wireframe shader.
+// Back faces are culled for the other shaders, allowing for mirroring transforms.
+func Draw_Mesh(p *raster.Pipeline, s raster.Shader) {
+	if ws, ok := s.(*Wireframe_Shader); ok {
+		p.Draw_Lines(ws, ws.Lines())
+		return
+	}
+	if ms, ok := s.(interface {
+		faces() int
+		cull() raster.Cull_Mode
+	}); ok {
+		p.Cull = ms.cull()
+		p.Draw(s, ms.faces())
+	}
+}
+
+//-----------------------------------------------------------------------------
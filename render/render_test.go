@@ -0,0 +1,274 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/raster"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+// a shader drawing a full screen triangle pair with a color, a normal and an id output
+type test_shader struct {
+	z float32
+}
+
+func (s *test_shader) Varyings() []raster.Qualifier {
+	return nil
+}
+
+func (s *test_shader) Vertex(i, k int) raster.Vertex {
+	quad := [2][3]vec.V4{
+		{{-1, -1, s.z, 1}, {1, -1, s.z, 1}, {1, 1, s.z, 1}},
+		{{-1, -1, s.z, 1}, {1, 1, s.z, 1}, {-1, 1, s.z, 1}},
+	}
+	return raster.Vertex{Position: quad[i][k]}
+}
+
+func (s *test_shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	return vec.V4{1, 0, 0, 1}, true
+}
+
+func (s *test_shader) Fragment_MRT(f *raster.Fragment, out []vec.V4) bool {
+	if f.X == 0 && f.Y == 0 {
+		return false
+	}
+	out[0] = vec.V4{1, 0, 0, 1}
+	out[1] = vec.V4{0.25, 0.5, float32(f.Y), 1}
+	out[2] = ID_Value(uint32(0x1234567 + f.X))
+	return true
+}
+
+func Test_Framebuffer(t *testing.T) {
+	for _, origin := range []Origin{Bottom_Left, Top_Left} {
+		fb := New_Framebuffer(4, 3, origin)
+		color_buf, _ := fb.Attach("color", RGBA8)
+		normal, _ := fb.Attach("normal", RGBA32F)
+		id, _ := fb.Attach("id", ID32)
+		depth, _ := fb.Attach("depth", Depth32F)
+		stencil, _ := fb.Attach("stencil", Stencil8)
+		if _, err := fb.Attach("color", RGBA8); err == nil {
+			t.Error("FAIL")
+		}
+		if _, err := fb.Attach("depth2", Depth32F); err == nil {
+			t.Error("FAIL")
+		}
+		if fb.Outputs() != 3 || fb.Depth() == nil || fb.Get("none") != nil {
+			t.Fatal("FAIL")
+		}
+
+		color_buf.Clear_Value = vec.V4{0, 0, 1, 1}
+		stencil.Clear_Value = vec.V4{7, 0, 0, 0}
+		fb.Clear()
+		p := fb.Pipeline()
+		p.Draw(&test_shader{z: 0}, 2)
+
+		// all outputs are written, except for the discarded fragment
+		if color_buf.Get(1, 0) != (vec.V4{1, 0, 0, 1}) || color_buf.Get(0, 0) != (vec.V4{0, 0, 1, 1}) {
+			t.Error("FAIL")
+		}
+		if normal.Get(2, 1) != (vec.V4{0.25, 0.5, 1, 1}) || id.ID(3, 2) != 0x1234567+3 || id.ID(0, 0) != 0 {
+			t.Error("FAIL")
+		}
+		if depth.Get(1, 0)[0] != 0.5 || depth.Get(0, 0)[0] != 1 || stencil.Get(2, 2)[0] != 7 {
+			t.Error("FAIL")
+		}
+
+		// a farther quad fails the depth test
+		p.Draw(&test_shader{z: 0.5}, 2)
+		if depth.Get(1, 0)[0] != 0.5 {
+			t.Error("FAIL")
+		}
+
+		// exported images are the right way up for the origin
+		img, err := fb.Image("color")
+		if err != nil {
+			t.Fatal(err)
+		}
+		y := 2 // window y = 0 is the bottom row of the image
+		if origin == Top_Left {
+			y = 0
+		}
+		if img.At(0, y) != (color.NRGBA{0, 0, 255, 255}) || img.At(1, y) != (color.NRGBA{255, 0, 0, 255}) {
+			t.Errorf("FAIL %d", origin)
+		}
+		img, _ = fb.Image("id")
+		if img.At(3, y) != (color.NRGBA{0x23, 0x45, 0x6a, 255}) {
+			t.Errorf("FAIL %v", img.At(3, y))
+		}
+		img, _ = fb.Image("depth")
+		if img.At(0, y) != (color.Gray16{0xffff}) || img.At(1, y) != (color.Gray16{0x8000}) {
+			t.Errorf("FAIL %v", img.At(1, y))
+		}
+		img, _ = fb.Image("normal")
+		if r, g, _, _ := img.At(2, 1).RGBA(); r != 0x4000 || g != 0x8000 {
+			t.Errorf("FAIL %v", img.At(2, 1))
+		}
+		img, _ = fb.Image("stencil")
+		if img.At(3, 2) != (color.Gray{7}) {
+			t.Error("FAIL")
+		}
+		if _, err := fb.Image("none"); err == nil {
+			t.Error("FAIL")
+		}
+
+		// resize
+		fb.Resize(8, 6)
+		if fb.Depth().Width != 8 || depth.Get(7, 5)[0] != 1 || color_buf.Get(7, 5) != (vec.V4{0, 0, 1, 1}) {
+			t.Error("FAIL")
+		}
+		if b := color_buf.Image().Bounds(); b.Dx() != 8 || b.Dy() != 6 {
+			t.Error("FAIL")
+		}
+	}
+}
+
+// return true if a world position is inside the view of a camera
+func in_view(c *Camera, p vec.V3) bool {
+	q := c.View_Proj().MulV(p.ToV4(1))
+	w := q[3] * (1 + 1e-5)
+	return q[3] > 0 && -w <= q[0] && q[0] <= w && -w <= q[1] && q[1] <= w && -w <= q[2] && q[2] <= w
+}
+
+func Test_Camera(t *testing.T) {
+	c := New_Camera()
+	c.Eye = vec.V3{0, 0, 5}
+	c.Target = vec.V3{0, 0, 0}
+
+	// the target is on the view axis
+	if p := c.View().Mul_Point(c.Target); !p.Equal_Eps(vec.V3{0, 0, -5}, 1e-5) {
+		t.Errorf("FAIL %v", p)
+	}
+
+	// orbit keeps the distance, positive pitch raises the eye
+	c.Orbit(math.Pi/2, 0)
+	if !c.Eye.Equal_Eps(vec.V3{5, 0, 0}, 1e-5) {
+		t.Errorf("FAIL %v", c.Eye)
+	}
+	c.Orbit(0, math.Pi/4)
+	if abs(c.Distance()-5) > 1e-5 || c.Eye[1] <= 0 || abs(c.Eye[1]-c.Eye[0]) > 1e-5 {
+		t.Errorf("FAIL %v", c.Eye)
+	}
+	// the pitch stops short of the up direction
+	c.Orbit(0, math.Pi)
+	if c.Eye[1] >= 5 || c.Eye[1] < 4.99 || !(c.Right().Length() > 0.99) {
+		t.Errorf("FAIL %v", c.Eye)
+	}
+
+	// views along the up direction, and orbits away from them
+	for _, eye := range []vec.V3{{0, 5, 0}, {0, -5, 0}} {
+		c := New_Camera()
+		c.Eye = eye
+		if p := c.View().Mul_Point(c.Target); !p.Equal_Eps(vec.V3{0, 0, -5}, 1e-5) {
+			t.Errorf("FAIL %v %v", eye, p)
+		}
+		if r := c.Right(); abs(r.Length()-1) > 1e-5 || abs(r.Dot(eye)) > 1e-5 {
+			t.Errorf("FAIL %v %v", eye, r)
+		}
+		c.Orbit(math.Pi/2, 0)
+		if !c.Eye.Equal_Eps(eye, 1e-5) {
+			t.Errorf("FAIL %v %v", eye, c.Eye)
+		}
+		c.Orbit(0, -eye[1]/10)
+		if abs(c.Distance()-5) > 1e-5 || abs(c.Eye[1]) > 4.9 {
+			t.Errorf("FAIL %v %v", eye, c.Eye)
+		}
+	}
+
+	// pan moves the eye and target together
+	c.Eye, c.Target = vec.V3{0, 0, 5}, vec.V3{}
+	c.Pan(1, 2)
+	if !c.Eye.Equal_Eps(vec.V3{1, 2, 5}, 1e-5) || !c.Target.Equal_Eps(vec.V3{1, 2, 0}, 1e-5) {
+		t.Errorf("FAIL %v %v", c.Eye, c.Target)
+	}
+
+	// dolly
+	c.Dolly(0.5)
+	if abs(c.Distance()-2.5) > 1e-5 {
+		t.Error("FAIL")
+	}
+
+	// frame a box from any direction with either projection and aspect
+	lo, hi := vec.V3{-1, 2, 3}, vec.V3{4, 3, 5}
+	for _, proj := range []Projection{Perspective, Orthographic} {
+		for _, aspect := range []float32{0.5, 1, 2} {
+			for _, eye := range []vec.V3{{0, 0, 10}, {3, -4, 1}, {-1, 1, -1}, {0, 1, 0}, {0, -1, 0}} {
+				c := New_Camera()
+				c.Projection = proj
+				c.Aspect = aspect
+				c.Eye = eye
+				c.Frame(lo, hi)
+				if !c.Target.Equal_Eps(lo.Add(hi).Scale(0.5), 1e-5) || !c.Forward().Equal_Eps(eye.Neg().Normalize(), 1e-3) {
+					t.Errorf("FAIL %s %f %v", proj, aspect, eye)
+				}
+				for i := 0; i < 8; i++ {
+					p := vec.V3{lo[0], lo[1], lo[2]}
+					for j := 0; j < 3; j++ {
+						if i&(1<<j) != 0 {
+							p[j] = hi[j]
+						}
+					}
+					if !in_view(c, p) {
+						t.Errorf("FAIL %s %f %v %v", proj, aspect, eye, p)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_Shaders(t *testing.T) {
+	mesh := &wavefront.Mesh{
+		Positions: []vec.V3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Faces:     [][3]int{{0, 1, 2}},
+		Material:  []int{-1},
+	}
+	// scale the model by 2 along x, the normal stays along z
+	model := vec.Scale(vec.V3{2, 1, 1})
+	ms := New_Mesh_Shader(mesh, model, vec.Identity_M4(), vec.V3{0, 0, 3})
+	if ms.Light != (vec.V3{0, 0, 1}) || ms.normal(0, 1) != (vec.V3{0, 0, 1}) {
+		t.Error("FAIL")
+	}
+
+	// wireframe edges
+	w := &Wireframe_Shader{ms}
+	if w.Lines() != 3 {
+		t.Fatal("FAIL")
+	}
+	for i := 0; i < 3; i++ {
+		a, b := w.Vertex(i, 0).Position, w.Vertex(i, 1).Position
+		if a.ToV3() != model.Mul_Point(mesh.Positions[i]) || b.ToV3() != model.Mul_Point(mesh.Positions[(i+1)%3]) {
+			t.Errorf("FAIL %d", i)
+		}
+	}
+
+	// depth: window depth or linear in view space between near and far
+	d := &Depth_Shader{Mesh_Shader: ms}
+	if c, _ := d.Fragment(&raster.Fragment{Z: 0.25}); c != (vec.V4{0.75, 0.75, 0.75, 1}) {
+		t.Error("FAIL")
+	}
+	d.Near, d.Far = 1, 3
+	// window depth for a view distance of 2
+	z := (1 - d.Near/2) * d.Far / (d.Far - d.Near)
+	if c, _ := d.Fragment(&raster.Fragment{Z: z}); abs(c[0]-0.5) > 1e-6 {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// phong with an mtl material without Ns: diffuse only, no highlight
+	mtl, err := wavefront.Parse_MTL(strings.NewReader("newmtl grey\nKd 0.5 0.5 0.5\n"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh.Materials, mesh.Material = mtl, []int{0}
+	lighting := &light.Lighting{Lights: []*light.Light{light.New_Directional(vec.V3{0, 0, -1}, vec.V3{1, 1, 1}, 1)}}
+	p := New_Phong_Shader(ms, lighting, vec.V3{0, 0, 5})
+	f := &raster.Fragment{}
+	copy(f.Varyings[:], []float32{0, 0, 1, 0, 0, 0})
+	if c, _ := p.Fragment(f); !c.Equal_Eps(vec.V4{0.5, 0.5, 0.5, 1}, 1e-6) {
+		t.Errorf("FAIL %v", c)
+	}
+}
@@ -0,0 +1,340 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Shaders
+
+Shaders for drawing a wavefront mesh with the raster pipeline.
+
+Flat - one light level per triangle.
+Gouraud - light levels at the vertices interpolated across the triangle.
+Phong - normals and positions interpolated and lit per pixel.
+Textured - diffuse texture lookups scaled by the gouraud light level.
+Depth - depth as a grey level (near is white).
+Normal - world normals as colors.
+Wireframe - triangle edges drawn as lines (use Draw_Lines with Lines()).
+
+The flat, gouraud and textured shaders use a single light direction, the
+phong shader uses a set of lights and the mesh materials.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/raster"
+	"github.com/deadsy/sw_render/texture"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+//-----------------------------------------------------------------------------
+
+// Mesh_Shader is the state common to the mesh shaders
+type Mesh_Shader struct {
+	Mesh  *wavefront.Mesh
+	Light vec.V3 // unit vector towards the light
+	model vec.M4 // object to world transform
+	mvp   vec.M4 // object to clip transform
+	nm    vec.M3 // object to world normal transform
+}
+
+// New_Mesh_Shader returns the common shader state for a mesh with object to
+// world and world to clip transforms.
+func New_Mesh_Shader(mesh *wavefront.Mesh, model, view_proj vec.M4, light vec.V3) Mesh_Shader {
+	return Mesh_Shader{
+		Mesh:  mesh,
+		Light: light.Normalize(),
+		model: model,
+		mvp:   view_proj.Mul(model),
+		nm:    model.Normal_Matrix(),
+	}
+}
+
+// return the number of triangles
+func (s *Mesh_Shader) faces() int {
+	return len(s.Mesh.Faces)
+}
+
+// return the cull mode for the back faces, a mirroring transform reverses the winding
+func (s *Mesh_Shader) cull() raster.Cull_Mode {
+	if s.model.M3().Det() < 0 {
+		return raster.Cull_Front
+	}
+	return raster.Cull_Back
+}
+
+// return the clip space position of vertex k of triangle i
+func (s *Mesh_Shader) position(i, k int) vec.V4 {
+	return s.mvp.MulV(s.Mesh.Positions[s.Mesh.Faces[i][k]].ToV4(1))
+}
+
+// return the world position of vertex k of triangle i
+func (s *Mesh_Shader) world(i, k int) vec.V3 {
+	return s.model.Mul_Point(s.Mesh.Positions[s.Mesh.Faces[i][k]])
+}
+
+// return the world face normal of triangle i
+func (s *Mesh_Shader) face_normal(i int) vec.V3 {
+	return s.nm.MulV(s.Mesh.Face_Normal(i)).Normalize()
+}
+
+// return the world normal of vertex k of triangle i
+func (s *Mesh_Shader) normal(i, k int) vec.V3 {
+	if s.Mesh.Normals == nil {
+		return s.face_normal(i)
+	}
+	return s.nm.MulV(s.Mesh.Normals[s.Mesh.Faces[i][k]]).Normalize()
+}
+
+// return the color of triangle i for a light level
+func (s *Mesh_Shader) color(i int, level float32) vec.V4 {
+	c := vec.V3{1, 1, 1}
+	if m := s.Mesh.Get_Material(i); m != nil {
+		c = m.Kd
+	}
+	return c.Scale(max(level, 0)).ToV4(1)
+}
+
+// return a normal as a color
+func normal_color(n vec.V3) vec.V4 {
+	return n.Scale(0.5).Add(vec.V3{0.5, 0.5, 0.5}).ToV4(1)
+}
+
+//-----------------------------------------------------------------------------
+
+// Flat_Shader has one light level per triangle
+type Flat_Shader struct{ Mesh_Shader }
+
+func (s *Flat_Shader) Varyings() []raster.Qualifier {
+	return []raster.Qualifier{raster.Flat}
+}
+
+func (s *Flat_Shader) Vertex(i, k int) raster.Vertex {
+	v := raster.Vertex{Position: s.position(i, k)}
+	v.Varyings[0] = s.Light.Dot(s.face_normal(i))
+	return v
+}
+
+func (s *Flat_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	return s.color(f.Tri, f.Varyings[0]), true
+}
+
+//-----------------------------------------------------------------------------
+
+// Gouraud_Shader interpolates the vertex light levels across the triangle
+type Gouraud_Shader struct{ Mesh_Shader }
+
+func (s *Gouraud_Shader) Varyings() []raster.Qualifier {
+	return []raster.Qualifier{raster.Smooth}
+}
+
+func (s *Gouraud_Shader) Vertex(i, k int) raster.Vertex {
+	v := raster.Vertex{Position: s.position(i, k)}
+	v.Varyings[0] = s.Light.Dot(s.normal(i, k))
+	return v
+}
+
+func (s *Gouraud_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	return s.color(f.Tri, f.Varyings[0]), true
+}
+
+//-----------------------------------------------------------------------------
+
+// Phong_Shader interpolates normals and positions and lights each pixel
+type Phong_Shader struct {
+	Mesh_Shader
+	Lighting  *light.Lighting
+	Eye       vec.V3           // viewer position
+	materials []light.Material // for each mesh material
+}
+
+// New_Phong_Shader returns a phong shader with the mtl lighting values of the mesh materials
+func New_Phong_Shader(ms Mesh_Shader, lighting *light.Lighting, eye vec.V3) *Phong_Shader {
+	s := &Phong_Shader{
+		Mesh_Shader: ms,
+		Lighting:    lighting,
+		Eye:         eye,
+		materials:   make([]light.Material, len(ms.Mesh.Materials)),
+	}
+	for i, m := range ms.Mesh.Materials {
+		s.materials[i] = light.From_MTL(m)
+	}
+	return s
+}
+
+func (s *Phong_Shader) Varyings() []raster.Qualifier {
+	return []raster.Qualifier{
+		raster.Smooth, raster.Smooth, raster.Smooth, // normal
+		raster.Smooth, raster.Smooth, raster.Smooth, // world position
+	}
+}
+
+func (s *Phong_Shader) Vertex(i, k int) raster.Vertex {
+	v := raster.Vertex{Position: s.position(i, k)}
+	n := s.normal(i, k)
+	p := s.world(i, k)
+	copy(v.Varyings[0:], n[:])
+	copy(v.Varyings[3:], p[:])
+	return v
+}
+
+func (s *Phong_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	n := vec.V3{f.Varyings[0], f.Varyings[1], f.Varyings[2]}.Normalize()
+	p := vec.V3{f.Varyings[3], f.Varyings[4], f.Varyings[5]}
+	m := &light.Default_Material
+	if k := s.Mesh.Material[f.Tri]; k >= 0 {
+		m = &s.materials[k]
+	}
+	return s.Lighting.Shade(m, p, n, s.Eye.Sub(p).Normalize()).ToV4(1), true
+}
+
+// Fragment_MRT writes the color and the normal (as a color) to the outputs
+func (s *Phong_Shader) Fragment_MRT(f *raster.Fragment, out []vec.V4) bool {
+	out[0], _ = s.Fragment(f)
+	if len(out) > 1 {
+		out[1] = normal_color(vec.V3{f.Varyings[0], f.Varyings[1], f.Varyings[2]}.Normalize())
+	}
+	return true
+}
+
+//-----------------------------------------------------------------------------
+
+// Textured_Shader looks up the diffuse textures with the obj texture coordinates
+type Textured_Shader struct {
+	Mesh_Shader
+	Sampler texture.Sampler
+	tex     []*texture.Texture // diffuse texture for each material (nil for none)
+}
+
+// New_Textured_Shader returns a textured shader with the diffuse textures of the mesh materials
+func New_Textured_Shader(ms Mesh_Shader) (*Textured_Shader, error) {
+	s := &Textured_Shader{
+		Mesh_Shader: ms,
+		Sampler:     texture.Sampler{Filter: texture.Trilinear},
+		tex:         make([]*texture.Texture, len(ms.Mesh.Materials)),
+	}
+	for i, m := range ms.Mesh.Materials {
+		if m.Map_Kd == nil {
+			continue
+		}
+		t, err := texture.Load(m.Map_Kd.Path)
+		if err != nil {
+			return nil, err
+		}
+		t.Generate_Mipmaps()
+		s.tex[i] = t
+	}
+	return s, nil
+}
+
+// Textured returns true if any material has a texture
+func (s *Textured_Shader) Textured() bool {
+	for _, t := range s.tex {
+		if t != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Textured_Shader) Varyings() []raster.Qualifier {
+	return []raster.Qualifier{raster.Smooth, raster.Smooth, raster.Smooth}
+}
+
+func (s *Textured_Shader) Vertex(i, k int) raster.Vertex {
+	v := raster.Vertex{Position: s.position(i, k)}
+	if s.Mesh.UVs != nil {
+		uv := s.Mesh.UVs[s.Mesh.Faces[i][k]]
+		v.Varyings[0], v.Varyings[1] = uv[0], uv[1]
+	}
+	v.Varyings[2] = s.Light.Dot(s.normal(i, k))
+	return v
+}
+
+func (s *Textured_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	level := f.Varyings[2]
+	m := s.Mesh.Material[f.Tri]
+	if m < 0 || s.tex[m] == nil || s.Mesh.UVs == nil {
+		return s.color(f.Tri, level), true
+	}
+	uv := vec.V2{f.Varyings[0], f.Varyings[1]}
+	dx := vec.V2{f.Dx(0), f.Dx(1)}
+	dy := vec.V2{f.Dy(0), f.Dy(1)}
+	c := s.Sampler.Sample_Grad(s.tex[m], uv, dx, dy)
+	return c.ToV3().Scale(max(level, 0)).ToV4(1), true
+}
+
+//-----------------------------------------------------------------------------
+
+// Depth_Shader writes the depth as a grey level (near is white).
+// With a perspective near and far distance the depth is linear in view space.
+type Depth_Shader struct {
+	Mesh_Shader
+	Near, Far float32 // perspective clip plane distances (0 for window depth)
+}
+
+func (s *Depth_Shader) Varyings() []raster.Qualifier {
+	return nil
+}
+
+func (s *Depth_Shader) Vertex(i, k int) raster.Vertex {
+	return raster.Vertex{Position: s.position(i, k)}
+}
+
+func (s *Depth_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	z := f.Z
+	if s.Far > s.Near && s.Near > 0 {
+		// view distance from window depth, mapped to [0,1]
+		d := s.Near * s.Far / (s.Far - z*(s.Far-s.Near))
+		z = (d - s.Near) / (s.Far - s.Near)
+	}
+	g := 1 - z
+	return vec.V4{g, g, g, 1}, true
+}
+
+//-----------------------------------------------------------------------------
+
+// Normal_Shader writes the interpolated world normals as colors
+type Normal_Shader struct{ Mesh_Shader }
+
+func (s *Normal_Shader) Varyings() []raster.Qualifier {
+	return []raster.Qualifier{raster.Smooth, raster.Smooth, raster.Smooth}
+}
+
+func (s *Normal_Shader) Vertex(i, k int) raster.Vertex {
+	v := raster.Vertex{Position: s.position(i, k)}
+	n := s.normal(i, k)
+	copy(v.Varyings[0:], n[:])
+	return v
+}
+
+func (s *Normal_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	return normal_color(vec.V3{f.Varyings[0], f.Varyings[1], f.Varyings[2]}.Normalize()), true
+}
+
+//-----------------------------------------------------------------------------
+
+// Wireframe_Shader draws the triangle edges in the material colors.
+// Line i is edge i % 3 of triangle i / 3.
+type Wireframe_Shader struct{ Mesh_Shader }
+
+// Lines returns the number of lines to draw
+func (s *Wireframe_Shader) Lines() int {
+	return 3 * len(s.Mesh.Faces)
+}
+
+func (s *Wireframe_Shader) Varyings() []raster.Qualifier {
+	return nil
+}
+
+func (s *Wireframe_Shader) Vertex(i, k int) raster.Vertex {
+	return raster.Vertex{Position: s.position(i/3, (i+k)%3)}
+}
+
+func (s *Wireframe_Shader) Fragment(f *raster.Fragment) (vec.V4, bool) {
+	return s.color(f.Tri/3, 1), true
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,56 @@
+//-----------------------------------------------------------------------------
+/*
+
+Scene Rendering
+
+A pass draws its objects into a framebuffer with the pass camera and mode.
+The flat, gouraud and textured modes light each object with the direction
+towards the first light from the object center.
+
+*/
+//-----------------------------------------------------------------------------
+
+package scene
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/deadsy/sw_render/render"
+)
+
+//-----------------------------------------------------------------------------
+
+// Render draws a pass and returns the image
+func (s *Scene) Render(p *Pass) (image.Image, error) {
+	fb := render.New_Framebuffer(s.Width, s.Height, render.Bottom_Left)
+	c, err := fb.Attach("color", render.RGBA8)
+	if err != nil {
+		return nil, err
+	}
+	c.Clear_Value = s.Background
+	if p.Mode != render.Wireframe {
+		if _, err := fb.Attach("depth", render.Depth32F); err != nil {
+			return nil, err
+		}
+	}
+	fb.Clear()
+
+	camera := &p.Camera.Camera
+	view_proj := camera.View_Proj()
+	pipeline := fb.Pipeline()
+	pipeline.Workers = s.Workers
+	for _, o := range p.Objects {
+		lo, hi := o.bounds()
+		dir, _ := s.Lighting.Lights[0].Incident(lo.Add(hi).Scale(0.5))
+		ms := render.New_Mesh_Shader(o.Mesh, o.Transform, view_proj, dir)
+		sh, err := render.New_Shader(p.Mode, ms, s.Lighting, camera)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", o.Name, err)
+		}
+		render.Draw_Mesh(pipeline, sh)
+	}
+	return fb.Image("color")
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,309 @@
+//-----------------------------------------------------------------------------
+/*
+
+Scene Descriptions
+
+A scene file describes a render in JSON (.json) or YAML (.yaml, .yml):
+obj models with per-instance transforms and material overrides, lights,
+cameras, output settings and render passes. Relative paths are resolved
+against the directory of the scene file.
+
+	output:
+	  width: 800
+	  height: 600
+	  background: [0.1, 0.1, 0.2]   # rgb or rgba
+	lighting: blinn-phong           # or phong
+	ambient: [0.1, 0.1, 0.1]
+	objects:
+	  - name: head
+	    model: obj/african_head.obj
+	    translate: [0, 0, 0]
+	    rotate: [0, 30, 0]          # degrees about x, then y, then z
+	    scale: 1                    # uniform or [x, y, z]
+	    material:                   # overrides all materials of the model
+	      kd: [0.8, 0.6, 0.5]
+	      ks: [0.2, 0.2, 0.2]
+	      ns: 32
+	      texture: head_diffuse.tga
+	lights:
+	  - type: directional           # directional, point or spot
+	    direction: [-1, -1, -1]     # the direction the light shines in
+	    color: [1, 1, 1]
+	    intensity: 1
+	  - type: spot
+	    position: [0, 5, 0]
+	    direction: [0, -1, 0]
+	    inner: 15                   # cone half angles in degrees
+	    outer: 30
+	    attenuation: [1, 0.1, 0]    # constant, linear, quadratic
+	cameras:
+	  - name: front
+	    eye: [0, 0, 3]
+	    target: [0, 0, 0]
+	    up: [0, 1, 0]
+	    projection: perspective     # or orthographic
+	    fov: 45                     # vertical degrees (perspective)
+	    height: 2                   # view height (orthographic)
+	    near: 0.1
+	    far: 100
+	    frame: true                 # fit the objects from the eye direction
+	passes:
+	  - name: beauty
+	    camera: front               # default is the first camera
+	    mode: phong                 # a render mode, see render.Mode
+	    output: out/beauty.png
+	    objects: [head]             # default is all objects
+
+Without lights there is a white directional light from (-1, 1, 1). Without
+cameras there is a camera framing all objects from the +z direction.
+
+*/
+//-----------------------------------------------------------------------------
+
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/render"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/deadsy/sw_render/wavefront"
+)
+
+//-----------------------------------------------------------------------------
+
+// Format is a scene file format
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	}
+	return "unknown"
+}
+
+// Format_From_Filename returns the scene file format for a file extension
+func Format_From_Filename(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return JSON, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	}
+	return 0, fmt.Errorf("%s: unknown scene format (use .json, .yaml or .yml)", filename)
+}
+
+//-----------------------------------------------------------------------------
+
+// Material overrides the materials of an object. Nil values are not overridden.
+type Material struct {
+	Ka, Kd, Ks *vec.V3
+	Ns         *float32
+	Texture    string // diffuse texture path ("" for none)
+}
+
+// Object is an instance of a model
+type Object struct {
+	Name      string
+	Model     string    // model file path
+	Transform vec.M4    // object to world transform
+	Material  *Material // nil for none
+	Mesh      *wavefront.Mesh
+}
+
+// Camera is a named camera
+type Camera struct {
+	Name          string
+	Frame_Objects bool // frame the objects from the eye direction
+	render.Camera
+}
+
+// Pass renders objects with a camera and a render mode to an image file
+type Pass struct {
+	Name    string
+	Camera  *Camera
+	Mode    render.Mode
+	Output  string // image file path
+	Objects []*Object
+}
+
+// Scene is a set of objects, lights, cameras and render passes
+type Scene struct {
+	Width, Height int
+	Background    vec.V4
+	Lighting      *light.Lighting
+	Objects       []*Object
+	Cameras       []*Camera
+	Passes        []*Pass
+	Workers       int // rasterizer goroutines for Render (0 for serial rasterization)
+}
+
+//-----------------------------------------------------------------------------
+
+// Load reads a scene file, its models and checks its textures exist
+func Load(filename string) (*Scene, error) {
+	format, err := Format_From_Filename(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data, format, filepath.Dir(filename))
+	if err != nil {
+		// prefix each of the joined errors with the file name
+		if list, ok := err.(interface{ Unwrap() []error }); ok {
+			errs := list.Unwrap()
+			for i := range errs {
+				errs[i] = fmt.Errorf("%s: %w", filename, errs[i])
+			}
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return s, nil
+}
+
+// Parse returns the scene for the contents of a scene file.
+// Relative paths are resolved against dir.
+func Parse(data []byte, format Format, dir string) (*Scene, error) {
+	var f file_scene
+	if err := decode(data, format, &f); err != nil {
+		return nil, err
+	}
+	s, err := f.build(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.frame()
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// read the object models and apply the material overrides
+func (s *Scene) load() error {
+	objs := make(map[string]*wavefront.Object)
+	var errs errors_list
+	for i, o := range s.Objects {
+		path := fmt.Sprintf("objects[%d]", i)
+		obj, ok := objs[o.Model]
+		if !ok {
+			var err error
+			obj, err = wavefront.Read(o.Model, nil)
+			if err != nil {
+				errs.add(path+".model", "%s", err)
+				continue
+			}
+			objs[o.Model] = obj
+		}
+		// each instance has its own mesh for its material overrides
+		o.Mesh = obj.Mesh()
+		if len(o.Mesh.Faces) == 0 {
+			errs.add(path+".model", "%s: no faces to render", o.Model)
+		}
+		if o.Material != nil && o.Material.Texture != "" {
+			if _, err := os.Stat(o.Material.Texture); err != nil {
+				errs.add(path+".material.texture", "%s", err)
+			}
+		}
+		o.override()
+	}
+	return errs.err()
+}
+
+// replace the mesh materials with overridden copies
+func (o *Object) override() {
+	m := o.Material
+	if m == nil {
+		return
+	}
+	apply := func(mtl *wavefront.Material) *wavefront.Material {
+		if m.Ka != nil {
+			mtl.Ka = *m.Ka
+		}
+		if m.Kd != nil {
+			mtl.Kd = *m.Kd
+		}
+		if m.Ks != nil {
+			mtl.Ks = *m.Ks
+		}
+		if m.Ns != nil {
+			mtl.Ns = *m.Ns
+		}
+		if m.Texture != "" {
+			mtl.Map_Kd = &wavefront.Texture_Map{Filename: m.Texture, Path: m.Texture}
+		}
+		return mtl
+	}
+	mesh := o.Mesh
+	materials := make([]*wavefront.Material, len(mesh.Materials))
+	for i, mtl := range mesh.Materials {
+		c := *mtl
+		materials[i] = apply(&c)
+	}
+	// a material for the triangles without one
+	none := -1
+	for i, k := range mesh.Material {
+		if k >= 0 {
+			continue
+		}
+		if none < 0 {
+			d := light.Default_Material
+			materials = append(materials, apply(&wavefront.Material{Name: "override", Ka: d.Ka, Kd: d.Kd, Ks: d.Ks, Ns: d.Ns, D: 1}))
+			none = len(materials) - 1
+		}
+		mesh.Material[i] = none
+	}
+	mesh.Materials = materials
+}
+
+// return the world bounding box of an object
+func (o *Object) bounds() (lo, hi vec.V3) {
+	for i, p := range o.Mesh.Positions {
+		p = o.Transform.Mul_Point(p)
+		if i == 0 {
+			lo, hi = p, p
+			continue
+		}
+		lo, hi = lo.Min(p), hi.Max(p)
+	}
+	return lo, hi
+}
+
+// frame the objects with the framing cameras
+func (s *Scene) frame() {
+	var lo, hi vec.V3
+	for i, o := range s.Objects {
+		a, b := o.bounds()
+		if i == 0 {
+			lo, hi = a, b
+			continue
+		}
+		lo, hi = lo.Min(a), hi.Max(b)
+	}
+	for _, c := range s.Cameras {
+		if c.Frame_Objects {
+			c.Frame(lo, hi)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
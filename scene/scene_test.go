@@ -0,0 +1,257 @@
+package scene
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/render"
+	"github.com/deadsy/sw_render/vec"
+)
+
+// write a unit quad model facing +z to a directory
+func write_quad(t *testing.T, dir string) {
+	quad := "v -1 -1 0\nv 1 -1 0\nv 1 1 0\nv -1 1 0\nf 1 2 3 4\n"
+	if err := os.WriteFile(filepath.Join(dir, "quad.obj"), []byte(quad), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const yaml_scene = `
+output:
+  width: 40
+  height: 20
+  background: [0, 0, 1]
+objects:
+  - model: quad.obj
+    translate: [1, 2, 3]
+    scale: 2
+    material:
+      kd: [1, 0, 0]
+  - name: small
+    model: quad.obj
+    rotate: [0, 90, 0]
+    scale: [1, 0.5, 1]
+lights:
+  - type: point
+    position: [0, 0, 5]
+    attenuation: [1, 0, 0.1]
+cameras:
+  - name: front
+    eye: [0, 0, 10]
+  - name: framed
+    eye: [0, 0, 1]
+    frame: true
+    projection: orthographic
+passes:
+  - mode: flat
+    output: out/a.png
+    objects: [quad]
+  - camera: framed
+    output: /abs/b.png
+`
+
+const json_scene = `{
+  "output": {"width": 40, "height": 20, "background": [0, 0, 1]},
+  "objects": [
+    {"model": "quad.obj", "translate": [1, 2, 3], "scale": 2, "material": {"kd": [1, 0, 0]}},
+    {"name": "small", "model": "quad.obj", "rotate": [0, 90, 0], "scale": [1, 0.5, 1]}
+  ],
+  "lights": [{"type": "point", "position": [0, 0, 5], "attenuation": [1, 0, 0.1]}],
+  "cameras": [
+    {"name": "front", "eye": [0, 0, 10]},
+    {"name": "framed", "eye": [0, 0, 1], "frame": true, "projection": "orthographic"}
+  ],
+  "passes": [
+    {"mode": "flat", "output": "out/a.png", "objects": ["quad"]},
+    {"camera": "framed", "output": "/abs/b.png"}
+  ]
+}`
+
+func Test_Parse(t *testing.T) {
+	dir := t.TempDir()
+	write_quad(t, dir)
+	for format, data := range map[Format]string{YAML: yaml_scene, JSON: json_scene} {
+		s, err := Parse([]byte(data), format, dir)
+		if err != nil {
+			t.Fatalf("FAIL %s %s", format, err)
+		}
+		if s.Width != 40 || s.Height != 20 || s.Background != (vec.V4{0, 0, 1, 1}) {
+			t.Errorf("FAIL %s", format)
+		}
+		if len(s.Objects) != 2 || len(s.Cameras) != 2 || len(s.Passes) != 2 || len(s.Lighting.Lights) != 1 {
+			t.Fatalf("FAIL %s", format)
+		}
+
+		// transforms and paths
+		quad, small := s.Objects[0], s.Objects[1]
+		if quad.Name != "quad" || quad.Model != filepath.Join(dir, "quad.obj") {
+			t.Errorf("FAIL %s %s %s", format, quad.Name, quad.Model)
+		}
+		if p := quad.Transform.Mul_Point(vec.V3{1, 1, 0}); !p.Equal_Eps(vec.V3{3, 4, 3}, 1e-6) {
+			t.Errorf("FAIL %s %v", format, p)
+		}
+		if p := small.Transform.Mul_Point(vec.V3{1, 1, 0}); !p.Equal_Eps(vec.V3{0, 0.5, -1}, 1e-6) {
+			t.Errorf("FAIL %s %v", format, p)
+		}
+
+		// the material override adds a material for the faces without one
+		if m := quad.Mesh.Get_Material(0); m == nil || m.Kd != (vec.V3{1, 0, 0}) {
+			t.Errorf("FAIL %s", format)
+		}
+		if small.Mesh.Get_Material(0) != nil {
+			t.Errorf("FAIL %s", format)
+		}
+
+		// lights
+		l := s.Lighting.Lights[0]
+		if l.Kind != light.Point || l.Quadratic != 0.1 || s.Lighting.Model != light.Blinn_Phong {
+			t.Errorf("FAIL %s", format)
+		}
+
+		// cameras: the framed camera looks at the center of the objects
+		front, framed := s.Cameras[0], s.Cameras[1]
+		if front.Eye != (vec.V3{0, 0, 10}) || front.Aspect != 2 || front.Projection != render.Perspective {
+			t.Errorf("FAIL %s", format)
+		}
+		if framed.Projection != render.Orthographic || !framed.Target.Equal_Eps(vec.V3{1, 1.75, 1}, 1e-6) {
+			t.Errorf("FAIL %s %v", format, framed.Target)
+		}
+
+		// passes
+		a, b := s.Passes[0], s.Passes[1]
+		if a.Camera != front || a.Mode != render.Flat || a.Output != filepath.Join(dir, "out", "a.png") || len(a.Objects) != 1 {
+			t.Errorf("FAIL %s", format)
+		}
+		if b.Camera != framed || b.Mode != render.Phong || b.Output != "/abs/b.png" || len(b.Objects) != 2 {
+			t.Errorf("FAIL %s", format)
+		}
+	}
+}
+
+func Test_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write_quad(t, dir)
+
+	// all schema errors are reported with their paths
+	bad := `
+output:
+  background: [1, 2]
+lighting: toon
+objects:
+  - model: quad.obj
+    scale: [1, 2]
+  - model: quad.obj
+lights:
+  - type: spot
+    inner: 30
+    outer: 20
+  - type: laser
+cameras:
+  - eye: [0, 0, 0]
+    target: [0, 0, 0]
+    near: 10
+    far: 1
+passes:
+  - camera: none
+    mode: cartoon
+    output: a.xyz
+    objects: [quad, other]
+`
+	_, err := Parse([]byte(bad), YAML, dir)
+	if err == nil {
+		t.Fatal("FAIL")
+	}
+	for _, msg := range []string{
+		"output.background: need 3 (rgb) or 4 (rgba) values",
+		"lighting: unknown lighting model \"toon\"",
+		"objects[0].scale: need 1 or 3 values",
+		"objects[1].name: duplicate object name \"quad\"",
+		"lights[0]: need 0 <= inner < outer < 180 degrees",
+		"lights[1].type: unknown light type \"laser\"",
+		"cameras[0].eye: must not be the target",
+		"cameras[0]: need 0 < near < far",
+		"passes[0].camera: no camera \"none\"",
+		"passes[0].mode: unknown mode \"cartoon\"",
+		"passes[0].output: a.xyz: unknown image format",
+		"passes[0].objects[1]: no object \"other\"",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("FAIL missing \"%s\" in %s", msg, err)
+		}
+	}
+
+	// decoding errors
+	for _, test := range []struct {
+		format Format
+		data   string
+		msg    string
+	}{
+		{YAML, "objects:\n  - model: quad.obj\n    colour: red\n", "field colour not found"},
+		{YAML, "output:\n  width: wide\n", "line 2"},
+		{JSON, "{\n\"objects\": [\n{\"model\": \"quad.obj\", \"colour\": 1}]}", "unknown field \"colour\""},
+		{JSON, "{\n\"output\": {\n\"width\": \"wide\"}}", "line 3: output.width"},
+		{JSON, "{\n\"objects\": [,]}", "line 2"},
+		{YAML, "", "objects: at least one object is required"},
+		{YAML, "objects:\n  - model: none.obj\npasses:\n  - output: a.png\n", "objects[0].model:"},
+		{YAML, "objects:\n  - model: quad.obj\n    material: {texture: none.tga}\npasses:\n  - output: a.png\n", "objects[0].material.texture:"},
+	} {
+		_, err := Parse([]byte(test.data), test.format, dir)
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("FAIL %q %v", test.data, err)
+		}
+	}
+
+	// file names
+	if _, err := Load(filepath.Join(dir, "scene.txt")); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := Load(filepath.Join(dir, "none.yaml")); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Render(t *testing.T) {
+	dir := t.TempDir()
+	write_quad(t, dir)
+	file := filepath.Join(dir, "scene.yml")
+	data := "output: {width: 16, height: 16, background: [0, 0, 1]}\n" +
+		"objects:\n  - model: quad.obj\n    material: {kd: [1, 0, 0]}\n    scale: 0.5\n" +
+		"lights:\n  - {type: directional, direction: [0, 0, -1]}\n" +
+		"passes:\n  - {mode: flat, output: a.png}\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := s.Render(s.Passes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default camera frames the quad, lit head on
+	if c := color.NRGBAModel.Convert(img.At(8, 8)); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("FAIL %v", c)
+	}
+	if c := color.NRGBAModel.Convert(img.At(0, 0)); c != (color.NRGBA{0, 0, 255, 255}) {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// a mirrored quad is not culled
+	mirror := strings.Replace(data, "scale: 0.5", "scale: [-0.5, 0.5, 0.5]", 1)
+	s, err = Parse([]byte(mirror), YAML, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err = s.Render(s.Passes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(img.At(8, 8)); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("FAIL %v", c)
+	}
+}
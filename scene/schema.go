@@ -0,0 +1,492 @@
+//-----------------------------------------------------------------------------
+/*
+
+Scene File Schema
+
+The scene file is decoded into the file_* structures with unknown fields
+rejected, then checked and converted to a scene. All schema errors are
+reported together, each with the path of the value in the file, e.g.
+
+	objects[1].scale: need 1 or 3 values
+
+*/
+//-----------------------------------------------------------------------------
+
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/deadsy/sw_render/light"
+	"github.com/deadsy/sw_render/render"
+	"github.com/deadsy/sw_render/vec"
+	"github.com/disintegration/imaging"
+	"gopkg.in/yaml.v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// numbers is a list of numbers, a single number is a list of one
+type numbers []float32
+
+func (n *numbers) UnmarshalJSON(b []byte) error {
+	var x float32
+	if json.Unmarshal(b, &x) == nil {
+		*n = numbers{x}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]float32)(n))
+}
+
+func (n *numbers) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var x float32
+		if err := node.Decode(&x); err != nil {
+			return err
+		}
+		*n = numbers{x}
+		return nil
+	}
+	return node.Decode((*[]float32)(n))
+}
+
+type file_output struct {
+	Width      int     `json:"width" yaml:"width"`
+	Height     int     `json:"height" yaml:"height"`
+	Background numbers `json:"background" yaml:"background"`
+}
+
+type file_material struct {
+	Ka      numbers  `json:"ka" yaml:"ka"`
+	Kd      numbers  `json:"kd" yaml:"kd"`
+	Ks      numbers  `json:"ks" yaml:"ks"`
+	Ns      *float32 `json:"ns" yaml:"ns"`
+	Texture string   `json:"texture" yaml:"texture"`
+}
+
+type file_object struct {
+	Name      string         `json:"name" yaml:"name"`
+	Model     string         `json:"model" yaml:"model"`
+	Translate numbers        `json:"translate" yaml:"translate"`
+	Rotate    numbers        `json:"rotate" yaml:"rotate"`
+	Scale     numbers        `json:"scale" yaml:"scale"`
+	Material  *file_material `json:"material" yaml:"material"`
+}
+
+type file_light struct {
+	Type        string   `json:"type" yaml:"type"`
+	Position    numbers  `json:"position" yaml:"position"`
+	Direction   numbers  `json:"direction" yaml:"direction"`
+	Color       numbers  `json:"color" yaml:"color"`
+	Intensity   *float32 `json:"intensity" yaml:"intensity"`
+	Attenuation numbers  `json:"attenuation" yaml:"attenuation"`
+	Inner       float32  `json:"inner" yaml:"inner"`
+	Outer       float32  `json:"outer" yaml:"outer"`
+}
+
+type file_camera struct {
+	Name       string  `json:"name" yaml:"name"`
+	Eye        numbers `json:"eye" yaml:"eye"`
+	Target     numbers `json:"target" yaml:"target"`
+	Up         numbers `json:"up" yaml:"up"`
+	Projection string  `json:"projection" yaml:"projection"`
+	Fov        float32 `json:"fov" yaml:"fov"`
+	Height     float32 `json:"height" yaml:"height"`
+	Near       float32 `json:"near" yaml:"near"`
+	Far        float32 `json:"far" yaml:"far"`
+	Frame      bool    `json:"frame" yaml:"frame"`
+}
+
+type file_pass struct {
+	Name    string   `json:"name" yaml:"name"`
+	Camera  string   `json:"camera" yaml:"camera"`
+	Mode    string   `json:"mode" yaml:"mode"`
+	Output  string   `json:"output" yaml:"output"`
+	Objects []string `json:"objects" yaml:"objects"`
+}
+
+type file_scene struct {
+	Output   file_output   `json:"output" yaml:"output"`
+	Lighting string        `json:"lighting" yaml:"lighting"`
+	Ambient  numbers       `json:"ambient" yaml:"ambient"`
+	Objects  []file_object `json:"objects" yaml:"objects"`
+	Lights   []file_light  `json:"lights" yaml:"lights"`
+	Cameras  []file_camera `json:"cameras" yaml:"cameras"`
+	Passes   []file_pass   `json:"passes" yaml:"passes"`
+}
+
+//-----------------------------------------------------------------------------
+
+// return the line number of a byte offset
+func line_number(data []byte, ofs int64) int {
+	return bytes.Count(data[:min(int(ofs), len(data))], []byte("\n")) + 1
+}
+
+// decode a scene file, rejecting unknown fields
+func decode(data []byte, format Format, f *file_scene) error {
+	if format == YAML {
+		d := yaml.NewDecoder(bytes.NewReader(data))
+		d.KnownFields(true)
+		err := d.Decode(f)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	err := d.Decode(f)
+	var syntax_err *json.SyntaxError
+	var type_err *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax_err):
+		return fmt.Errorf("line %d: %s", line_number(data, syntax_err.Offset), err)
+	case errors.As(err, &type_err):
+		return fmt.Errorf("line %d: %s: cannot use %s as %s", line_number(data, type_err.Offset), type_err.Field, type_err.Value, type_err.Type)
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// errors_list collects schema errors
+type errors_list []error
+
+// add an error for the value at a path
+func (e *errors_list) add(path, format string, a ...any) {
+	*e = append(*e, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+}
+
+// return the errors as one error (nil for none)
+func (e errors_list) err() error {
+	return errors.Join(e...)
+}
+
+// return a 3 vector value, or the default for none
+func (e *errors_list) v3(path string, n numbers, dflt vec.V3) vec.V3 {
+	switch len(n) {
+	case 0:
+		return dflt
+	case 3:
+		return vec.V3{n[0], n[1], n[2]}
+	}
+	e.add(path, "need 3 values, have %d", len(n))
+	return dflt
+}
+
+// return an optional 3 vector value (nil for none)
+func (e *errors_list) v3_ptr(path string, n numbers) *vec.V3 {
+	if len(n) == 0 {
+		return nil
+	}
+	v := e.v3(path, n, vec.V3{})
+	return &v
+}
+
+// return a non-zero 3 vector value, or the default for none
+func (e *errors_list) dir(path string, n numbers, dflt vec.V3) vec.V3 {
+	v := e.v3(path, n, dflt)
+	if v.Length() == 0 {
+		e.add(path, "must not be zero")
+		return dflt
+	}
+	return v
+}
+
+// return an rgb or rgba color (alpha is 1 for rgb)
+func (e *errors_list) color(path string, n numbers, dflt vec.V4) vec.V4 {
+	switch len(n) {
+	case 0:
+		return dflt
+	case 3:
+		return vec.V4{n[0], n[1], n[2], 1}
+	case 4:
+		return vec.V4{n[0], n[1], n[2], n[3]}
+	}
+	e.add(path, "need 3 (rgb) or 4 (rgba) values, have %d", len(n))
+	return dflt
+}
+
+//-----------------------------------------------------------------------------
+
+// return the degrees as radians
+func radians(x float32) float32 {
+	return x * math.Pi / 180
+}
+
+// return a path resolved against a directory
+func resolve(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// check and convert the file structures to a scene
+func (f *file_scene) build(dir string) (*Scene, error) {
+	var errs errors_list
+	s := &Scene{
+		Width:  f.Output.Width,
+		Height: f.Output.Height,
+	}
+
+	// output
+	if s.Width == 0 && s.Height == 0 {
+		s.Width, s.Height = 750, 750
+	}
+	if s.Width <= 0 || s.Height <= 0 {
+		errs.add("output", "bad image size %dx%d", s.Width, s.Height)
+	}
+	s.Background = errs.color("output.background", f.Output.Background, vec.V4{0, 0, 0, 1})
+
+	// lighting
+	s.Lighting = &light.Lighting{}
+	switch f.Lighting {
+	case "", "blinn-phong":
+		s.Lighting.Model = light.Blinn_Phong
+	case "phong":
+		s.Lighting.Model = light.Phong
+	default:
+		errs.add("lighting", "unknown lighting model \"%s\" (blinn-phong, phong)", f.Lighting)
+	}
+	s.Lighting.Ambient = errs.v3("ambient", f.Ambient, vec.V3{0.1, 0.1, 0.1})
+	for i := range f.Lights {
+		if l := f.Lights[i].build(fmt.Sprintf("lights[%d]", i), &errs); l != nil {
+			s.Lighting.Lights = append(s.Lighting.Lights, l)
+		}
+	}
+	if len(f.Lights) == 0 {
+		s.Lighting.Lights = []*light.Light{light.New_Directional(vec.V3{1, -1, -1}, vec.V3{1, 1, 1}, 1)}
+	}
+
+	// objects
+	objects := make(map[string]*Object)
+	if len(f.Objects) == 0 {
+		errs.add("objects", "at least one object is required")
+	}
+	for i := range f.Objects {
+		path := fmt.Sprintf("objects[%d]", i)
+		o := f.Objects[i].build(path, dir, &errs)
+		if objects[o.Name] != nil {
+			errs.add(path+".name", "duplicate object name \"%s\"", o.Name)
+		}
+		objects[o.Name] = o
+		s.Objects = append(s.Objects, o)
+	}
+
+	// cameras
+	cameras := make(map[string]*Camera)
+	aspect := float32(max(s.Width, 1)) / float32(max(s.Height, 1))
+	for i := range f.Cameras {
+		path := fmt.Sprintf("cameras[%d]", i)
+		c := f.Cameras[i].build(path, &errs)
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("camera%d", i)
+		}
+		if cameras[c.Name] != nil {
+			errs.add(path+".name", "duplicate camera name \"%s\"", c.Name)
+		}
+		c.Aspect = aspect
+		cameras[c.Name] = c
+		s.Cameras = append(s.Cameras, c)
+	}
+	if len(f.Cameras) == 0 {
+		c := &Camera{Name: "default", Frame_Objects: true, Camera: *render.New_Camera()}
+		c.Aspect = aspect
+		s.Cameras = []*Camera{c}
+	}
+
+	// passes
+	if len(f.Passes) == 0 {
+		errs.add("passes", "at least one pass is required")
+	}
+	outputs := make(map[string]bool)
+	for i := range f.Passes {
+		path := fmt.Sprintf("passes[%d]", i)
+		fp := &f.Passes[i]
+		p := &Pass{
+			Name:   fp.Name,
+			Camera: s.Cameras[0],
+			Output: resolve(dir, fp.Output),
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("pass%d", i)
+		}
+		if fp.Camera != "" {
+			if p.Camera = cameras[fp.Camera]; p.Camera == nil {
+				errs.add(path+".camera", "no camera \"%s\"", fp.Camera)
+			}
+		}
+		if fp.Mode != "" {
+			var err error
+			if p.Mode, err = render.Parse_Mode(fp.Mode); err != nil {
+				errs.add(path+".mode", "%s", err)
+			}
+		} else {
+			p.Mode = render.Phong
+		}
+		if fp.Output == "" {
+			errs.add(path+".output", "missing output file")
+		} else if _, err := imaging.FormatFromFilename(fp.Output); err != nil {
+			errs.add(path+".output", "%s: unknown image format", fp.Output)
+		} else if outputs[p.Output] {
+			errs.add(path+".output", "%s: already written by another pass", fp.Output)
+		}
+		outputs[p.Output] = true
+		p.Objects = s.Objects
+		if len(fp.Objects) != 0 {
+			p.Objects = nil
+			for j, name := range fp.Objects {
+				o := objects[name]
+				if o == nil {
+					errs.add(fmt.Sprintf("%s.objects[%d]", path, j), "no object \"%s\"", name)
+					continue
+				}
+				p.Objects = append(p.Objects, o)
+			}
+		}
+		s.Passes = append(s.Passes, p)
+	}
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// check and convert an object
+func (f *file_object) build(path, dir string, errs *errors_list) *Object {
+	o := &Object{
+		Name:  f.Name,
+		Model: resolve(dir, f.Model),
+	}
+	if f.Model == "" {
+		errs.add(path+".model", "missing model file")
+	}
+	if o.Name == "" {
+		o.Name = filepath.Base(f.Model)
+		o.Name = o.Name[:len(o.Name)-len(filepath.Ext(o.Name))]
+	}
+	t := errs.v3(path+".translate", f.Translate, vec.V3{})
+	r := errs.v3(path+".rotate", f.Rotate, vec.V3{})
+	scale := vec.V3{1, 1, 1}
+	switch len(f.Scale) {
+	case 0:
+	case 1:
+		scale = vec.V3{f.Scale[0], f.Scale[0], f.Scale[0]}
+	case 3:
+		scale = vec.V3{f.Scale[0], f.Scale[1], f.Scale[2]}
+	default:
+		errs.add(path+".scale", "need 1 or 3 values, have %d", len(f.Scale))
+	}
+	if scale[0]*scale[1]*scale[2] == 0 {
+		errs.add(path+".scale", "must not be zero")
+	}
+	q := vec.Euler(vec.V3{radians(r[0]), radians(r[1]), radians(r[2])}, vec.XYZ)
+	o.Transform = vec.Translate(t).Mul(q.M4()).Mul(vec.Scale(scale))
+	if m := f.Material; m != nil {
+		o.Material = &Material{
+			Ka:      errs.v3_ptr(path+".material.ka", m.Ka),
+			Kd:      errs.v3_ptr(path+".material.kd", m.Kd),
+			Ks:      errs.v3_ptr(path+".material.ks", m.Ks),
+			Ns:      m.Ns,
+			Texture: resolve(dir, m.Texture),
+		}
+		if m.Ns != nil && *m.Ns < 0 {
+			errs.add(path+".material.ns", "must not be negative")
+		}
+	}
+	return o
+}
+
+// check and convert a light (nil for an unknown type)
+func (f *file_light) build(path string, errs *errors_list) *light.Light {
+	color := errs.v3(path+".color", f.Color, vec.V3{1, 1, 1})
+	intensity := float32(1)
+	if f.Intensity != nil {
+		intensity = *f.Intensity
+	}
+	var l *light.Light
+	switch f.Type {
+	case "directional":
+		l = light.New_Directional(errs.dir(path+".direction", f.Direction, vec.V3{0, 0, -1}), color, intensity)
+	case "point":
+		l = light.New_Point(errs.v3(path+".position", f.Position, vec.V3{}), color, intensity)
+	case "spot":
+		if f.Inner < 0 || f.Outer <= f.Inner || f.Outer >= 180 {
+			errs.add(path, "need 0 <= inner < outer < 180 degrees, have inner %g outer %g", f.Inner, f.Outer)
+		}
+		pos := errs.v3(path+".position", f.Position, vec.V3{})
+		dir := errs.dir(path+".direction", f.Direction, vec.V3{0, 0, -1})
+		l = light.New_Spot(pos, dir, radians(f.Inner), radians(f.Outer), color, intensity)
+	case "":
+		errs.add(path+".type", "missing light type (directional, point, spot)")
+		return nil
+	default:
+		errs.add(path+".type", "unknown light type \"%s\" (directional, point, spot)", f.Type)
+		return nil
+	}
+	if len(f.Attenuation) != 0 {
+		if l.Kind == light.Directional {
+			errs.add(path+".attenuation", "directional lights have no attenuation")
+		}
+		a := errs.v3(path+".attenuation", f.Attenuation, vec.V3{1, 0, 0})
+		l.Attenuation(a[0], a[1], a[2])
+	}
+	return l
+}
+
+// check and convert a camera
+func (f *file_camera) build(path string, errs *errors_list) *Camera {
+	c := &Camera{
+		Name:          f.Name,
+		Frame_Objects: f.Frame,
+		Camera:        *render.New_Camera(),
+	}
+	c.Eye = errs.v3(path+".eye", f.Eye, c.Eye)
+	c.Target = errs.v3(path+".target", f.Target, c.Target)
+	c.Up = errs.dir(path+".up", f.Up, c.Up)
+	if c.Eye == c.Target {
+		errs.add(path+".eye", "must not be the target")
+	}
+	switch f.Projection {
+	case "", "perspective":
+		c.Projection = render.Perspective
+	case "orthographic":
+		c.Projection = render.Orthographic
+	default:
+		errs.add(path+".projection", "unknown projection \"%s\" (perspective, orthographic)", f.Projection)
+	}
+	if f.Fov != 0 {
+		if f.Fov < 0 || f.Fov >= 180 {
+			errs.add(path+".fov", "need 0 < fov < 180 degrees, have %g", f.Fov)
+		}
+		c.Fov = radians(f.Fov)
+	}
+	if f.Height != 0 {
+		if f.Height < 0 {
+			errs.add(path+".height", "must be positive")
+		}
+		c.Height = f.Height
+	}
+	if f.Near != 0 {
+		c.Near = f.Near
+	}
+	if f.Far != 0 {
+		c.Far = f.Far
+	}
+	if c.Near <= 0 || c.Far <= c.Near {
+		errs.add(path, "need 0 < near < far, have near %g far %g", c.Near, c.Far)
+	}
+	return c
+}
+
+//-----------------------------------------------------------------------------
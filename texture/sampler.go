@@ -0,0 +1,148 @@
+//-----------------------------------------------------------------------------
+/*
+
+Texture Sampling
+
+A sampler looks up a texture at u,v coordinates with a filter and a wrap
+mode for each axis. u,v = (0,0) is the bottom left corner of the texture and
+(1,1) is the top right. Texel centers are at half integer texel positions.
+
+Nearest and bilinear filters use the full resolution level. The trilinear
+filter blends bilinear samples from the two mipmap levels nearest to a level
+of detail. The level of detail is given directly, or is found from the u,v
+derivatives across a pixel.
+
+*/
+//-----------------------------------------------------------------------------
+
+package texture
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Filter is a texture filter
+type Filter int
+
+const (
+	Nearest   Filter = iota // nearest texel
+	Bilinear                // blend of the 4 nearest texels
+	Trilinear               // blend of bilinear samples from 2 mipmap levels
+)
+
+// Wrap is a mode for texture coordinates outside [0,1]
+type Wrap int
+
+const (
+	Repeat Wrap = iota // tile the texture
+	Clamp              // use the edge texels
+	Mirror             // tile the texture, flipping every other tile
+)
+
+// Sampler samples textures
+type Sampler struct {
+	Filter Filter
+	Wrap_U Wrap
+	Wrap_V Wrap
+}
+
+//-----------------------------------------------------------------------------
+
+// return a texel index wrapped to [0, n)
+func wrap(i, n int, mode Wrap) int {
+	switch mode {
+	case Clamp:
+		return min(max(i, 0), n-1)
+	case Mirror:
+		i %= 2 * n
+		if i < 0 {
+			i += 2 * n
+		}
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i
+	}
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// return the floor of x as an int
+func ifloor(x float32) int {
+	return int(math.Floor(float64(x)))
+}
+
+// return the nearest texel of a level
+func (s *Sampler) nearest(l *level, uv vec.V2) vec.V4 {
+	x := wrap(ifloor(uv[0]*float32(l.w)), l.w, s.Wrap_U)
+	y := wrap(ifloor(uv[1]*float32(l.h)), l.h, s.Wrap_V)
+	return l.get(x, y)
+}
+
+// return the bilinear blend of the 4 nearest texels of a level
+func (s *Sampler) bilinear(l *level, uv vec.V2) vec.V4 {
+	fx := uv[0]*float32(l.w) - 0.5
+	fy := uv[1]*float32(l.h) - 0.5
+	x, y := ifloor(fx), ifloor(fy)
+	fx -= float32(x)
+	fy -= float32(y)
+	x0 := wrap(x, l.w, s.Wrap_U)
+	x1 := wrap(x+1, l.w, s.Wrap_U)
+	y0 := wrap(y, l.h, s.Wrap_V)
+	y1 := wrap(y+1, l.h, s.Wrap_V)
+	c0 := l.get(x0, y0).Lerp(l.get(x1, y0), fx)
+	c1 := l.get(x0, y1).Lerp(l.get(x1, y1), fx)
+	return c0.Lerp(c1, fy)
+}
+
+//-----------------------------------------------------------------------------
+
+// Sample returns the texture color at uv from the full resolution level
+func (s *Sampler) Sample(t *Texture, uv vec.V2) vec.V4 {
+	return s.Sample_Lod(t, uv, 0)
+}
+
+// Sample_Lod returns the texture color at uv for a level of detail.
+// Only the trilinear filter uses the level of detail.
+func (s *Sampler) Sample_Lod(t *Texture, uv vec.V2, lod float32) vec.V4 {
+	switch s.Filter {
+	case Nearest:
+		return s.nearest(t.levels[0], uv)
+	case Bilinear:
+		return s.bilinear(t.levels[0], uv)
+	}
+	last := float32(len(t.levels) - 1)
+	lod = min(max(lod, 0), last)
+	l0 := ifloor(lod)
+	l1 := min(l0+1, len(t.levels)-1)
+	c0 := s.bilinear(t.levels[l0], uv)
+	if l1 == l0 {
+		return c0
+	}
+	return c0.Lerp(s.bilinear(t.levels[l1], uv), lod-float32(l0))
+}
+
+// Sample_Grad returns the texture color at uv with the level of detail
+// found from the uv derivatives in the x and y pixel directions.
+func (s *Sampler) Sample_Grad(t *Texture, uv, dx, dy vec.V2) vec.V4 {
+	return s.Sample_Lod(t, uv, t.Lod(dx, dy))
+}
+
+// Lod returns the level of detail for uv derivatives in the x and y pixel directions
+func (t *Texture) Lod(dx, dy vec.V2) float32 {
+	size := vec.V2{float32(t.Width), float32(t.Height)}
+	rho := max(dx.Mul(size).Length(), dy.Mul(size).Length())
+	if rho <= 0 {
+		return 0
+	}
+	return float32(math.Log2(float64(rho)))
+}
+
+//-----------------------------------------------------------------------------
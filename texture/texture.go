@@ -0,0 +1,125 @@
+//-----------------------------------------------------------------------------
+/*
+
+Textures
+
+A texture is a stack of mipmap levels of RGBA float32 texels with
+components in [0,1]. Level 0 is the full resolution image, each following
+level is half the size of the previous one down to 1x1.
+
+Texel (0,0) is the bottom left of the image, so texture coordinates from an
+OBJ file (v up) map directly onto the texture.
+
+*/
+//-----------------------------------------------------------------------------
+
+package texture
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // register the gif decoder
+	_ "image/jpeg" // register the jpeg decoder
+	_ "image/png"  // register the png decoder
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// a mipmap level
+type level struct {
+	w, h  int
+	texel []vec.V4 // rows from the bottom up
+}
+
+// return the texel at x, y
+func (l *level) get(x, y int) vec.V4 {
+	return l.texel[y*l.w+x]
+}
+
+// Texture is a mipmapped RGBA texture
+type Texture struct {
+	Width, Height int
+	levels        []*level
+}
+
+// New returns a texture (without mipmaps) for an image
+func New(img image.Image) *Texture {
+	r := img.Bounds()
+	l := &level{w: r.Dx(), h: r.Dy()}
+	l.texel = make([]vec.V4, l.w*l.h)
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			c, g, b, a := img.At(r.Min.X+x, r.Max.Y-1-y).RGBA()
+			t := vec.V4{float32(c), float32(g), float32(b), float32(a)}.Scale(1.0 / 0xffff)
+			if a != 0 {
+				// not pre-multiplied
+				t = vec.V4{t[0] / t[3], t[1] / t[3], t[2] / t[3], t[3]}
+			}
+			l.texel[y*l.w+x] = t
+		}
+	}
+	return &Texture{
+		Width:  l.w,
+		Height: l.h,
+		levels: []*level{l},
+	}
+}
+
+// Load reads a texture from a tga file or any format known to image.Decode
+func Load(path string) (*Texture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var img image.Image
+	if strings.ToLower(filepath.Ext(path)) == ".tga" {
+		img, err = Decode_TGA(f)
+	} else {
+		img, _, err = image.Decode(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return New(img), nil
+}
+
+// Levels returns the number of mipmap levels
+func (t *Texture) Levels() int {
+	return len(t.levels)
+}
+
+// Get returns the texel at x, y of a mipmap level
+func (t *Texture) Get(lod, x, y int) vec.V4 {
+	return t.levels[lod].get(x, y)
+}
+
+// Generate_Mipmaps builds the mipmap levels by 2x2 box filtering.
+// Level sizes are rounded down.
+func (t *Texture) Generate_Mipmaps() {
+	t.levels = t.levels[:1]
+	l := t.levels[0]
+	for l.w > 1 || l.h > 1 {
+		m := &level{w: max(l.w/2, 1), h: max(l.h/2, 1)}
+		m.texel = make([]vec.V4, m.w*m.h)
+		for y := 0; y < m.h; y++ {
+			y0 := min(2*y, l.h-1)
+			y1 := min(2*y+1, l.h-1)
+			for x := 0; x < m.w; x++ {
+				x0 := min(2*x, l.w-1)
+				x1 := min(2*x+1, l.w-1)
+				c := l.get(x0, y0).Add(l.get(x1, y0)).Add(l.get(x0, y1)).Add(l.get(x1, y1))
+				m.texel[y*m.w+x] = c.Scale(0.25)
+			}
+		}
+		t.levels = append(t.levels, m)
+		l = m
+	}
+}
+
+//-----------------------------------------------------------------------------
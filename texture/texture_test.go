@@ -0,0 +1,234 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+// a 3x2 test image, top row first
+var tga_pixels = [2][3]color.NRGBA{
+	{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}},
+	{{255, 255, 255, 128}, {255, 255, 255, 128}, {10, 20, 30, 40}},
+}
+
+// return a 32 bit tga file for the test image
+func tga_file(rle, top bool) []byte {
+	var buf bytes.Buffer
+	h := tga_header{Image_Type: tga_true_color, Width: 3, Height: 2, Depth: 32, Descriptor: 8}
+	if rle {
+		h.Image_Type |= tga_rle
+	}
+	if top {
+		h.Descriptor |= tga_top_to_bottom
+	}
+	binary.Write(&buf, binary.LittleEndian, &h)
+	rows := []int{1, 0}
+	if top {
+		rows = []int{0, 1}
+	}
+	pixel := func(c color.NRGBA) []byte {
+		return []byte{c.B, c.G, c.R, c.A}
+	}
+	for _, y := range rows {
+		p := tga_pixels[y]
+		if !rle {
+			for _, c := range p {
+				buf.Write(pixel(c))
+			}
+		} else if y == 0 {
+			// a raw packet
+			buf.WriteByte(2)
+			for _, c := range p {
+				buf.Write(pixel(c))
+			}
+		} else {
+			// a run packet and a raw packet
+			buf.WriteByte(0x81)
+			buf.Write(pixel(p[0]))
+			buf.WriteByte(0)
+			buf.Write(pixel(p[2]))
+		}
+	}
+	return buf.Bytes()
+}
+
+func Test_TGA(t *testing.T) {
+	for _, rle := range []bool{false, true} {
+		for _, top := range []bool{false, true} {
+			img, err := Decode_TGA(bytes.NewReader(tga_file(rle, top)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := range tga_pixels {
+				for x, c := range tga_pixels[y] {
+					if img.At(x, y) != c {
+						t.Errorf("FAIL rle %v top %v (%d,%d) %v", rle, top, x, y, img.At(x, y))
+					}
+				}
+			}
+		}
+	}
+
+	// 8 bit grey scale
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &tga_header{Image_Type: tga_grey, Width: 2, Height: 1, Depth: 8})
+	buf.Write([]byte{0, 200})
+	img, err := Decode_TGA(&buf)
+	if err != nil || img.At(1, 0) != (color.NRGBA{200, 200, 200, 255}) {
+		t.Error("FAIL")
+	}
+
+	// truncated and unsupported files
+	if _, err := Decode_TGA(bytes.NewReader(tga_file(true, false)[:30])); err == nil {
+		t.Error("FAIL")
+	}
+	buf.Reset()
+	binary.Write(&buf, binary.LittleEndian, &tga_header{Image_Type: 32, Width: 2, Height: 1, Depth: 8})
+	if _, err := Decode_TGA(&buf); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+	tga := filepath.Join(dir, "a.tga")
+	if err := os.WriteFile(tga, tga_file(true, false), 0644); err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for y := range tga_pixels {
+		for x, c := range tga_pixels[y] {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	png_file := filepath.Join(dir, "a.png")
+	if err := os.WriteFile(png_file, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{tga, png_file} {
+		tex, err := Load(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// texel (0,0) is the bottom left
+		if tex.Width != 3 || tex.Height != 2 || !tex.Get(0, 0, 1).Equal(vec.V4{1, 0, 0, 1}) {
+			t.Errorf("FAIL %s", name)
+		}
+		if !tex.Get(0, 2, 0).Equal_Eps(vec.V4{10.0 / 255, 20.0 / 255, 30.0 / 255, 40.0 / 255}, 1e-2) {
+			t.Errorf("FAIL %s %v", name, tex.Get(0, 2, 0))
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "none.png")); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+// return a texture with a gradient in red and a checkerboard in green
+func test_texture(w, h int) *Texture {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 255 / (w - 1)), uint8((x + y) % 2 * 255), 0, 255})
+		}
+	}
+	return New(img)
+}
+
+func Test_Wrap(t *testing.T) {
+	tests := []struct {
+		mode Wrap
+		out  []int // for -5..5 with n = 4
+	}{
+		{Repeat, []int{3, 0, 1, 2, 3, 0, 1, 2, 3, 0, 1}},
+		{Clamp, []int{0, 0, 0, 0, 0, 0, 1, 2, 3, 3, 3}},
+		{Mirror, []int{3, 3, 2, 1, 0, 0, 1, 2, 3, 3, 2}},
+	}
+	for _, x := range tests {
+		for i, k := range x.out {
+			if wrap(i-5, 4, x.mode) != k {
+				t.Errorf("FAIL %d %d", x.mode, i-5)
+			}
+		}
+	}
+}
+
+func Test_Sampler(t *testing.T) {
+	tex := test_texture(4, 4)
+
+	// nearest
+	s := Sampler{Filter: Nearest}
+	if c := s.Sample(tex, vec.V2{0.3, 0.1}); c[0] != 1.0/3 {
+		t.Errorf("FAIL %v", c)
+	}
+	if c := s.Sample(tex, vec.V2{1.1, 0.1}); c[0] != 0 {
+		t.Errorf("FAIL %v", c)
+	}
+	s.Wrap_U = Clamp
+	if c := s.Sample(tex, vec.V2{1.1, 0.1}); c[0] != 1 {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// bilinear: halfway between texel centers
+	s = Sampler{Filter: Bilinear, Wrap_U: Clamp, Wrap_V: Clamp}
+	if c := s.Sample(tex, vec.V2{0.25, 0.5}); abs(c[0]-1.0/6) > 1e-6 || abs(c[1]-0.5) > 1e-6 {
+		t.Errorf("FAIL %v", c)
+	}
+	// on a texel center
+	if c := s.Sample(tex, vec.V2{0.375, 0.375}); abs(c[0]-1.0/3) > 1e-6 {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// trilinear
+	tex.Generate_Mipmaps()
+	if tex.Levels() != 3 {
+		t.Fatal("FAIL")
+	}
+	if c := tex.Get(2, 0, 0); abs(c[0]-0.5) > 1e-6 || abs(c[1]-0.5) > 1e-6 {
+		t.Errorf("FAIL %v", c)
+	}
+	s.Filter = Trilinear
+	uv := vec.V2{0.375, 0.125}
+	c0 := s.Sample_Lod(tex, uv, 0)
+	c1 := s.Sample_Lod(tex, uv, 1)
+	if c := s.Sample_Lod(tex, uv, 0.5); !c.Equal_Eps(c0.Lerp(c1, 0.5), 1e-6) {
+		t.Errorf("FAIL %v", c)
+	}
+	// the checkerboard averages out at the smallest level
+	if c := s.Sample_Lod(tex, uv, 10); abs(c[1]-0.5) > 1e-6 {
+		t.Errorf("FAIL %v", c)
+	}
+	// one texel per pixel is level 0, four is level 2
+	if tex.Lod(vec.V2{0.25, 0}, vec.V2{0, 0.25}) != 0 || tex.Lod(vec.V2{1, 0}, vec.V2{0, 0.5}) != 2 {
+		t.Error("FAIL")
+	}
+	if c := s.Sample_Grad(tex, uv, vec.V2{1, 0}, vec.V2{0, 1}); !c.Equal_Eps(s.Sample_Lod(tex, uv, 2), 1e-6) {
+		t.Errorf("FAIL %v", c)
+	}
+
+	// odd sizes
+	tex = test_texture(5, 3)
+	tex.Generate_Mipmaps()
+	if tex.Levels() != 3 || tex.levels[1].w != 2 || tex.levels[1].h != 1 {
+		t.Error("FAIL")
+	}
+}
+
+// return the absolute value of x
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
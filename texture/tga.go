@@ -0,0 +1,230 @@
+//-----------------------------------------------------------------------------
+/*
+
+Truevision TGA Image Decoding
+
+Supports color mapped, true color and grey scale images, raw or run length
+encoded, with 8, 15, 16, 24 and 32 bits per pixel and either origin.
+
+*/
+//-----------------------------------------------------------------------------
+
+package texture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+//-----------------------------------------------------------------------------
+
+// tga image types
+const (
+	tga_color_mapped = 1
+	tga_true_color   = 2
+	tga_grey         = 3
+	tga_rle          = 8 // added to the types above
+)
+
+// tga file header
+type tga_header struct {
+	Id_Len     uint8
+	Cmap_Type  uint8
+	Image_Type uint8
+	Cmap_First uint16
+	Cmap_Len   uint16
+	Cmap_Depth uint8
+	X0, Y0     uint16
+	Width      uint16
+	Height     uint16
+	Depth      uint8
+	Descriptor uint8
+}
+
+// descriptor bits
+const (
+	tga_right_to_left = 1 << 4
+	tga_top_to_bottom = 1 << 5
+)
+
+//-----------------------------------------------------------------------------
+
+// decode a pixel of n bytes
+func tga_color(b []byte, alpha bool) color.NRGBA {
+	switch len(b) {
+	case 1:
+		return color.NRGBA{b[0], b[0], b[0], 255}
+	case 2:
+		// arrrrrgg gggbbbbb, little endian
+		x := uint16(b[0]) | uint16(b[1])<<8
+		c := color.NRGBA{
+			uint8((x >> 10 & 31) * 255 / 31),
+			uint8((x >> 5 & 31) * 255 / 31),
+			uint8((x & 31) * 255 / 31),
+			255,
+		}
+		if alpha && x>>15 == 0 {
+			c.A = 0
+		}
+		return c
+	case 3:
+		return color.NRGBA{b[2], b[1], b[0], 255}
+	}
+	c := color.NRGBA{b[2], b[1], b[0], b[3]}
+	if !alpha {
+		c.A = 255
+	}
+	return c
+}
+
+// read the header and check that the image is supported
+func tga_read_header(r io.Reader) (*tga_header, error) {
+	var h tga_header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	base := h.Image_Type &^ tga_rle
+	switch base {
+	case tga_color_mapped:
+		if h.Cmap_Type != 1 || (h.Depth != 8 && h.Depth != 16) {
+			return nil, errors.New("tga: bad color map")
+		}
+		switch h.Cmap_Depth {
+		case 15, 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("tga: unsupported color map depth %d", h.Cmap_Depth)
+		}
+	case tga_true_color:
+		switch h.Depth {
+		case 15, 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("tga: unsupported depth %d", h.Depth)
+		}
+	case tga_grey:
+		if h.Depth != 8 {
+			return nil, fmt.Errorf("tga: unsupported depth %d", h.Depth)
+		}
+	default:
+		return nil, fmt.Errorf("tga: unsupported image type %d", h.Image_Type)
+	}
+	if h.Width == 0 || h.Height == 0 {
+		return nil, errors.New("tga: empty image")
+	}
+	return &h, nil
+}
+
+// Decode_TGA_Config returns the dimensions and color model of a tga image
+func Decode_TGA_Config(r io.Reader) (image.Config, error) {
+	h, err := tga_read_header(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      int(h.Width),
+		Height:     int(h.Height),
+	}, nil
+}
+
+// Decode_TGA decodes a tga image
+func Decode_TGA(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := tga_read_header(br)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := br.Discard(int(h.Id_Len)); err != nil {
+		return nil, err
+	}
+
+	// bytes per pixel and alpha
+	n := (int(h.Depth) + 7) / 8
+	alpha := h.Descriptor&15 != 0
+
+	// color map
+	var cmap []color.NRGBA
+	if h.Cmap_Type == 1 {
+		k := (int(h.Cmap_Depth) + 7) / 8
+		buf := make([]byte, int(h.Cmap_Len)*k)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		if h.Image_Type&^tga_rle == tga_color_mapped {
+			cmap = make([]color.NRGBA, h.Cmap_Len)
+			for i := range cmap {
+				cmap[i] = tga_color(buf[i*k:(i+1)*k], alpha || h.Cmap_Depth == 32)
+			}
+		}
+	}
+
+	// pixel data in file order
+	w, ht := int(h.Width), int(h.Height)
+	data := make([]byte, w*ht*n)
+	if h.Image_Type&tga_rle == 0 {
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := 0; i < len(data); {
+			hdr, err := br.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			count := int(hdr&0x7f) + 1
+			if i+count*n > len(data) {
+				return nil, errors.New("tga: bad run length packet")
+			}
+			if hdr&0x80 != 0 {
+				// run of a single pixel
+				if _, err := io.ReadFull(br, data[i:i+n]); err != nil {
+					return nil, err
+				}
+				for j := 1; j < count; j++ {
+					copy(data[i+j*n:], data[i:i+n])
+				}
+			} else {
+				// raw pixels
+				if _, err := io.ReadFull(br, data[i:i+count*n]); err != nil {
+					return nil, err
+				}
+			}
+			i += count * n
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, ht))
+	for i := 0; i < w*ht; i++ {
+		b := data[i*n : (i+1)*n]
+		var c color.NRGBA
+		if cmap != nil {
+			k := int(b[0])
+			if n == 2 {
+				k |= int(b[1]) << 8
+			}
+			k -= int(h.Cmap_First)
+			if k < 0 || k >= len(cmap) {
+				return nil, errors.New("tga: bad color map index")
+			}
+			c = cmap[k]
+		} else {
+			c = tga_color(b, alpha)
+		}
+		x, y := i%w, i/w
+		if h.Descriptor&tga_right_to_left != 0 {
+			x = w - 1 - x
+		}
+		if h.Descriptor&tga_top_to_bottom == 0 {
+			// the default origin is the bottom left
+			y = ht - 1 - y
+		}
+		img.SetNRGBA(x, y, c)
+	}
+	return img, nil
+}
+
+//-----------------------------------------------------------------------------
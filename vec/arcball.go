@@ -0,0 +1,71 @@
+//-----------------------------------------------------------------------------
+/*
+
+Arcball Rotation
+
+A 2d window position is mapped onto a ball centered in the window.
+Dragging from one position to another rotates the ball by the angle between
+the two points on its surface. Positions outside the ball map to its rim, so
+dragging around the outside rotates about the view axis.
+
+The window x-axis is to the right and the y-axis is up.
+
+*/
+//-----------------------------------------------------------------------------
+
+package vec
+
+//-----------------------------------------------------------------------------
+
+// Arcball turns 2d drags into rotations
+type Arcball[T Float] struct {
+	Center      Vec2[T]       // window position of the ball center
+	Radius      T             // ball radius in window units
+	Orientation Quaternion[T] // current orientation
+}
+
+// New_Arcball returns an arcball with the identity orientation
+func New_Arcball[T Float](center Vec2[T], radius T) *Arcball[T] {
+	return &Arcball[T]{
+		Center:      center,
+		Radius:      radius,
+		Orientation: Identity_Quaternion[T](),
+	}
+}
+
+// map a window position to a unit vector on the ball
+func (a *Arcball[T]) point(p Vec2[T]) Vec3[T] {
+	d := p.Sub(a.Center).Scale(1 / a.Radius)
+	r2 := d.Dot(d)
+	if r2 >= 1 {
+		// on or outside the rim
+		return Vec3[T]{d[0], d[1], 0}.Normalize()
+	}
+	return Vec3[T]{d[0], d[1], sqrt(1 - r2)}
+}
+
+// Rotation returns the rotation for a drag between two window positions
+func (a *Arcball[T]) Rotation(from, to Vec2[T]) Quaternion[T] {
+	p0 := a.point(from)
+	p1 := a.point(to)
+	d := p0.Dot(p1)
+	if d < -1+1e-6 {
+		// opposite points on the rim: half turn about the view axis
+		return Quaternion[T]{0, 0, 1, 0}
+	}
+	c := p0.Cross(p1)
+	return Quaternion[T]{c[0], c[1], c[2], 1 + d}.Normalize()
+}
+
+// Drag rotates the orientation by a drag of delta from a window position
+func (a *Arcball[T]) Drag(from, delta Vec2[T]) {
+	q := a.Rotation(from, from.Add(delta))
+	a.Orientation = q.Mul(a.Orientation).Normalize()
+}
+
+// M4 returns the rotation matrix for the current orientation
+func (a *Arcball[T]) M4() Mat4[T] {
+	return a.Orientation.M4()
+}
+
+//-----------------------------------------------------------------------------
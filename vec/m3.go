@@ -0,0 +1,83 @@
+package vec
+
+// 3x3 matrix, m[row][col]
+type Mat3[T Float] [3][3]T
+
+// Return the identity matrix
+func Identity_Mat3[T Float]() Mat3[T] {
+	return Mat3[T]{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0, 0, 1},
+	}
+}
+
+// Return the float32 identity matrix
+func Identity_M3() M3 {
+	return Identity_Mat3[float32]()
+}
+
+// Return a * b
+func (a Mat3[T]) Mul(b Mat3[T]) Mat3[T] {
+	var m Mat3[T]
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return m
+}
+
+// Return a * v
+func (a Mat3[T]) MulV(v Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[0][0]*v[0] + a[0][1]*v[1] + a[0][2]*v[2],
+		a[1][0]*v[0] + a[1][1]*v[1] + a[1][2]*v[2],
+		a[2][0]*v[0] + a[2][1]*v[1] + a[2][2]*v[2],
+	}
+}
+
+// Return the transpose of a
+func (a Mat3[T]) Transpose() Mat3[T] {
+	var m Mat3[T]
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] = a[j][i]
+		}
+	}
+	return m
+}
+
+// Return the determinant of a
+func (a Mat3[T]) Det() T {
+	return a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+}
+
+// Return the inverse of a, false if a is singular
+func (a Mat3[T]) Inverse() (Mat3[T], bool) {
+	d := a.Det()
+	if d == 0 {
+		return Mat3[T]{}, false
+	}
+	k := 1 / d
+	// transposed cofactors
+	return Mat3[T]{
+		{
+			(a[1][1]*a[2][2] - a[1][2]*a[2][1]) * k,
+			(a[0][2]*a[2][1] - a[0][1]*a[2][2]) * k,
+			(a[0][1]*a[1][2] - a[0][2]*a[1][1]) * k,
+		},
+		{
+			(a[1][2]*a[2][0] - a[1][0]*a[2][2]) * k,
+			(a[0][0]*a[2][2] - a[0][2]*a[2][0]) * k,
+			(a[0][2]*a[1][0] - a[0][0]*a[1][2]) * k,
+		},
+		{
+			(a[1][0]*a[2][1] - a[1][1]*a[2][0]) * k,
+			(a[0][1]*a[2][0] - a[0][0]*a[2][1]) * k,
+			(a[0][0]*a[1][1] - a[0][1]*a[1][0]) * k,
+		},
+	}, true
+}
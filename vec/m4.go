@@ -0,0 +1,222 @@
+package vec
+
+import (
+	"math"
+)
+
+// 4x4 matrix, m[row][col]
+// Vectors are columns, so a * b applies b first and then a.
+type Mat4[T Float] [4][4]T
+
+// Return the identity matrix
+func Identity_Mat4[T Float]() Mat4[T] {
+	return Mat4[T]{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return the float32 identity matrix
+func Identity_M4() M4 {
+	return Identity_Mat4[float32]()
+}
+
+// Return a * b
+func (a Mat4[T]) Mul(b Mat4[T]) Mat4[T] {
+	var m Mat4[T]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			m[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j] + a[i][3]*b[3][j]
+		}
+	}
+	return m
+}
+
+// Return a * v
+func (a Mat4[T]) MulV(v Vec4[T]) Vec4[T] {
+	var x Vec4[T]
+	for i := 0; i < 4; i++ {
+		x[i] = a[i][0]*v[0] + a[i][1]*v[1] + a[i][2]*v[2] + a[i][3]*v[3]
+	}
+	return x
+}
+
+// Return a * p for a point p (w = 1) after the perspective divide
+func (a Mat4[T]) Mul_Point(p Vec3[T]) Vec3[T] {
+	return a.MulV(p.ToV4(1)).Divide()
+}
+
+// Return a * d for a direction d (w = 0)
+func (a Mat4[T]) Mul_Dir(d Vec3[T]) Vec3[T] {
+	return a.MulV(d.ToV4(0)).ToV3()
+}
+
+// Return the transpose of a
+func (a Mat4[T]) Transpose() Mat4[T] {
+	var m Mat4[T]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			m[i][j] = a[j][i]
+		}
+	}
+	return m
+}
+
+// Return the upper left 3x3 matrix of a
+func (a Mat4[T]) M3() Mat3[T] {
+	return Mat3[T]{
+		{a[0][0], a[0][1], a[0][2]},
+		{a[1][0], a[1][1], a[1][2]},
+		{a[2][0], a[2][1], a[2][2]},
+	}
+}
+
+// Return the matrix for transforming normals (inverse transpose of the upper 3x3)
+func (a Mat4[T]) Normal_Matrix() Mat3[T] {
+	m, ok := a.M3().Inverse()
+	if !ok {
+		return a.M3()
+	}
+	return m.Transpose()
+}
+
+// 2x2 sub-determinants of the upper and lower row pairs
+func (a Mat4[T]) sub_dets() (s, c [6]T) {
+	s[0] = a[0][0]*a[1][1] - a[1][0]*a[0][1]
+	s[1] = a[0][0]*a[1][2] - a[1][0]*a[0][2]
+	s[2] = a[0][0]*a[1][3] - a[1][0]*a[0][3]
+	s[3] = a[0][1]*a[1][2] - a[1][1]*a[0][2]
+	s[4] = a[0][1]*a[1][3] - a[1][1]*a[0][3]
+	s[5] = a[0][2]*a[1][3] - a[1][2]*a[0][3]
+	c[5] = a[2][2]*a[3][3] - a[3][2]*a[2][3]
+	c[4] = a[2][1]*a[3][3] - a[3][1]*a[2][3]
+	c[3] = a[2][1]*a[3][2] - a[3][1]*a[2][2]
+	c[2] = a[2][0]*a[3][3] - a[3][0]*a[2][3]
+	c[1] = a[2][0]*a[3][2] - a[3][0]*a[2][2]
+	c[0] = a[2][0]*a[3][1] - a[3][0]*a[2][1]
+	return
+}
+
+// Return the determinant of a
+func (a Mat4[T]) Det() T {
+	s, c := a.sub_dets()
+	return s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0]
+}
+
+// Return the inverse of a, false if a is singular
+func (a Mat4[T]) Inverse() (Mat4[T], bool) {
+	s, c := a.sub_dets()
+	d := s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0]
+	if d == 0 {
+		return Mat4[T]{}, false
+	}
+	k := 1 / d
+	var m Mat4[T]
+	m[0][0] = (a[1][1]*c[5] - a[1][2]*c[4] + a[1][3]*c[3]) * k
+	m[0][1] = (-a[0][1]*c[5] + a[0][2]*c[4] - a[0][3]*c[3]) * k
+	m[0][2] = (a[3][1]*s[5] - a[3][2]*s[4] + a[3][3]*s[3]) * k
+	m[0][3] = (-a[2][1]*s[5] + a[2][2]*s[4] - a[2][3]*s[3]) * k
+	m[1][0] = (-a[1][0]*c[5] + a[1][2]*c[2] - a[1][3]*c[1]) * k
+	m[1][1] = (a[0][0]*c[5] - a[0][2]*c[2] + a[0][3]*c[1]) * k
+	m[1][2] = (-a[3][0]*s[5] + a[3][2]*s[2] - a[3][3]*s[1]) * k
+	m[1][3] = (a[2][0]*s[5] - a[2][2]*s[2] + a[2][3]*s[1]) * k
+	m[2][0] = (a[1][0]*c[4] - a[1][1]*c[2] + a[1][3]*c[0]) * k
+	m[2][1] = (-a[0][0]*c[4] + a[0][1]*c[2] - a[0][3]*c[0]) * k
+	m[2][2] = (a[3][0]*s[4] - a[3][1]*s[2] + a[3][3]*s[0]) * k
+	m[2][3] = (-a[2][0]*s[4] + a[2][1]*s[2] - a[2][3]*s[0]) * k
+	m[3][0] = (-a[1][0]*c[3] + a[1][1]*c[1] - a[1][2]*c[0]) * k
+	m[3][1] = (a[0][0]*c[3] - a[0][1]*c[1] + a[0][2]*c[0]) * k
+	m[3][2] = (-a[3][0]*s[3] + a[3][1]*s[1] - a[3][2]*s[0]) * k
+	m[3][3] = (a[2][0]*s[3] - a[2][1]*s[1] + a[2][2]*s[0]) * k
+	return m, true
+}
+
+//-----------------------------------------------------------------------------
+// transforms
+
+// Return a translation by v
+func Translate[T Float](v Vec3[T]) Mat4[T] {
+	return Mat4[T]{
+		{1, 0, 0, v[0]},
+		{0, 1, 0, v[1]},
+		{0, 0, 1, v[2]},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return a scaling by the components of v
+func Scale[T Float](v Vec3[T]) Mat4[T] {
+	return Mat4[T]{
+		{v[0], 0, 0, 0},
+		{0, v[1], 0, 0},
+		{0, 0, v[2], 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return a counter clockwise rotation of angle radians about an axis
+func Rotate[T Float](axis Vec3[T], angle T) Mat4[T] {
+	a := axis.Normalize()
+	s := T(math.Sin(float64(angle)))
+	c := T(math.Cos(float64(angle)))
+	t := 1 - c
+	x, y, z := a[0], a[1], a[2]
+	return Mat4[T]{
+		{t*x*x + c, t*x*y - s*z, t*x*z + s*y, 0},
+		{t*x*y + s*z, t*y*y + c, t*y*z - s*x, 0},
+		{t*x*z - s*y, t*y*z + s*x, t*z*z + c, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return a view matrix for a camera at eye looking at center.
+// The camera looks down its -z axis with up along its +y axis.
+func LookAt[T Float](eye, center, up Vec3[T]) Mat4[T] {
+	f := center.Sub(eye).Normalize()
+	s := f.Cross(up).Normalize()
+	u := s.Cross(f)
+	return Mat4[T]{
+		{s[0], s[1], s[2], -s.Dot(eye)},
+		{u[0], u[1], u[2], -u.Dot(eye)},
+		{-f[0], -f[1], -f[2], f.Dot(eye)},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return a perspective projection.
+// fovy is the vertical field of view in radians, aspect is width/height.
+// The view frustum between near and far maps to z in [-1, 1].
+func Perspective[T Float](fovy, aspect, near, far T) Mat4[T] {
+	f := 1 / T(math.Tan(float64(fovy)/2))
+	d := near - far
+	return Mat4[T]{
+		{f / aspect, 0, 0, 0},
+		{0, f, 0, 0},
+		{0, 0, (far + near) / d, 2 * far * near / d},
+		{0, 0, -1, 0},
+	}
+}
+
+// Return an orthographic projection.
+// The box between the planes maps to the cube [-1, 1] (near to z = -1).
+func Orthographic[T Float](left, right, bottom, top, near, far T) Mat4[T] {
+	return Mat4[T]{
+		{2 / (right - left), 0, 0, -(right + left) / (right - left)},
+		{0, 2 / (top - bottom), 0, -(top + bottom) / (top - bottom)},
+		{0, 0, -2 / (far - near), -(far + near) / (far - near)},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return a viewport transform.
+// x and y in [-1, 1] map to [x0, x0 + w] and [y0, y0 + h], z in [-1, 1] maps to [0, 1].
+func Viewport[T Float](x0, y0, w, h T) Mat4[T] {
+	return Mat4[T]{
+		{w / 2, 0, 0, x0 + w/2},
+		{0, h / 2, 0, y0 + h/2},
+		{0, 0, 0.5, 0.5},
+		{0, 0, 0, 1},
+	}
+}
@@ -0,0 +1,239 @@
+//-----------------------------------------------------------------------------
+/*
+
+Quaternion Rotations
+
+A quaternion is stored as {x, y, z, w} with the vector part first.
+Rotation quaternions have unit length. Multiplying q * r gives the
+rotation r followed by q, the same order as for matrices.
+
+*/
+//-----------------------------------------------------------------------------
+
+package vec
+
+import (
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// quaternion {x, y, z, w}
+type Quaternion[T Float] [4]T
+
+type Quat = Quaternion[float32]
+type Quatd = Quaternion[float64]
+
+// Return the identity rotation
+func Identity_Quaternion[T Float]() Quaternion[T] {
+	return Quaternion[T]{0, 0, 0, 1}
+}
+
+// Return the float32 identity rotation
+func Identity_Quat() Quat {
+	return Identity_Quaternion[float32]()
+}
+
+// Return a counter clockwise rotation of angle radians about an axis
+func Axis_Angle[T Float](axis Vec3[T], angle T) Quaternion[T] {
+	a := axis.Normalize()
+	s := T(math.Sin(float64(angle) / 2))
+	c := T(math.Cos(float64(angle) / 2))
+	return Quaternion[T]{a[0] * s, a[1] * s, a[2] * s, c}
+}
+
+// Return the rotation axis and angle of a unit quaternion
+func (q Quaternion[T]) Axis_Angle() (Vec3[T], T) {
+	if q[3] < 0 {
+		q = q.Neg()
+	}
+	v := q.Vector()
+	s := v.Length()
+	if s == 0 {
+		return Vec3[T]{1, 0, 0}, 0
+	}
+	angle := 2 * T(math.Atan2(float64(s), float64(q[3])))
+	return v.Scale(1 / s), angle
+}
+
+// Return the vector part of q
+func (q Quaternion[T]) Vector() Vec3[T] {
+	return Vec3[T]{q[0], q[1], q[2]}
+}
+
+//-----------------------------------------------------------------------------
+// euler angles
+
+// Euler_Order is the order that euler angle rotations are applied
+type Euler_Order int
+
+const (
+	XYZ Euler_Order = iota // rotate about x, then y, then z
+	XZY
+	YXZ
+	YZX
+	ZXY
+	ZYX
+)
+
+// axes for each order
+var euler_axes = [...][3]int{
+	XYZ: {0, 1, 2},
+	XZY: {0, 2, 1},
+	YXZ: {1, 0, 2},
+	YZX: {1, 2, 0},
+	ZXY: {2, 0, 1},
+	ZYX: {2, 1, 0},
+}
+
+// Return the rotation for euler angles (radians about the fixed x, y and z axes).
+// The rotations are applied in the given order.
+func Euler[T Float](angles Vec3[T], order Euler_Order) Quaternion[T] {
+	q := Identity_Quaternion[T]()
+	for _, i := range euler_axes[order] {
+		var axis Vec3[T]
+		axis[i] = 1
+		q = Axis_Angle(axis, angles[i]).Mul(q)
+	}
+	return q
+}
+
+//-----------------------------------------------------------------------------
+// quaternion operations
+
+// Return a * b (the rotation b followed by a)
+func (a Quaternion[T]) Mul(b Quaternion[T]) Quaternion[T] {
+	return Quaternion[T]{
+		a[3]*b[0] + a[0]*b[3] + a[1]*b[2] - a[2]*b[1],
+		a[3]*b[1] - a[0]*b[2] + a[1]*b[3] + a[2]*b[0],
+		a[3]*b[2] + a[0]*b[1] - a[1]*b[0] + a[2]*b[3],
+		a[3]*b[3] - a[0]*b[0] - a[1]*b[1] - a[2]*b[2],
+	}
+}
+
+// Return -q (the same rotation as q)
+func (q Quaternion[T]) Neg() Quaternion[T] {
+	return Quaternion[T]{-q[0], -q[1], -q[2], -q[3]}
+}
+
+// Return the conjugate of q (the inverse rotation for a unit quaternion)
+func (q Quaternion[T]) Conjugate() Quaternion[T] {
+	return Quaternion[T]{-q[0], -q[1], -q[2], q[3]}
+}
+
+// Return a.b
+func (a Quaternion[T]) Dot(b Quaternion[T]) T {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+}
+
+// Return the length of q
+func (q Quaternion[T]) Length() T {
+	return sqrt(q.Dot(q))
+}
+
+// Normalize q
+func (q Quaternion[T]) Normalize() Quaternion[T] {
+	l := q.Length()
+	if l == 0 {
+		return Identity_Quaternion[T]()
+	}
+	return Quaternion[T]{q[0] / l, q[1] / l, q[2] / l, q[3] / l}
+}
+
+// Return the inverse of q
+func (q Quaternion[T]) Inverse() Quaternion[T] {
+	d := q.Dot(q)
+	c := q.Conjugate()
+	return Quaternion[T]{c[0] / d, c[1] / d, c[2] / d, c[3] / d}
+}
+
+// return true if each component of a is within eps of b
+func (a Quaternion[T]) Equal_Eps(b Quaternion[T], eps T) bool {
+	return Vec4[T](a).Equal_Eps(Vec4[T](b), eps)
+}
+
+// Return v rotated by the unit quaternion q
+func (q Quaternion[T]) Rotate(v Vec3[T]) Vec3[T] {
+	// v + 2w(u x v) + 2u x (u x v)
+	u := q.Vector()
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q[3])).Add(u.Cross(t))
+}
+
+//-----------------------------------------------------------------------------
+// interpolation
+
+// Return the normalized linear interpolation from a (t = 0) to b (t = 1).
+// The interpolation takes the shortest path.
+func (a Quaternion[T]) Nlerp(b Quaternion[T], t T) Quaternion[T] {
+	if a.Dot(b) < 0 {
+		b = b.Neg()
+	}
+	return Quaternion[T](Vec4[T](a).Lerp(Vec4[T](b), t)).Normalize()
+}
+
+// Return the spherical linear interpolation from a (t = 0) to b (t = 1).
+// The interpolation takes the shortest path at a constant angular velocity.
+func (a Quaternion[T]) Slerp(b Quaternion[T], t T) Quaternion[T] {
+	d := a.Dot(b)
+	if d < 0 {
+		b = b.Neg()
+		d = -d
+	}
+	if d > 0.9995 {
+		// nearly parallel
+		return a.Nlerp(b, t)
+	}
+	theta := math.Acos(float64(d))
+	s := math.Sin(theta)
+	ka := T(math.Sin((1-float64(t))*theta) / s)
+	kb := T(math.Sin(float64(t)*theta) / s)
+	return Quaternion[T](Vec4[T](a).Scale(ka).Add(Vec4[T](b).Scale(kb)))
+}
+
+//-----------------------------------------------------------------------------
+// matrix conversions
+
+// Return the rotation matrix for the unit quaternion q
+func (q Quaternion[T]) M3() Mat3[T] {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return Mat3[T]{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w)},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w)},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// Return the rotation matrix for the unit quaternion q
+func (q Quaternion[T]) M4() Mat4[T] {
+	r := q.M3()
+	return Mat4[T]{
+		{r[0][0], r[0][1], r[0][2], 0},
+		{r[1][0], r[1][1], r[1][2], 0},
+		{r[2][0], r[2][1], r[2][2], 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Return the rotation of the upper 3x3 part of m (which must be a rotation)
+func From_M4[T Float](m Mat4[T]) Quaternion[T] {
+	var q Quaternion[T]
+	trace := m[0][0] + m[1][1] + m[2][2]
+	switch {
+	case trace > 0:
+		s := 2 * sqrt(trace+1)
+		q = Quaternion[T]{(m[2][1] - m[1][2]) / s, (m[0][2] - m[2][0]) / s, (m[1][0] - m[0][1]) / s, s / 4}
+	case m[0][0] > m[1][1] && m[0][0] > m[2][2]:
+		s := 2 * sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q = Quaternion[T]{s / 4, (m[0][1] + m[1][0]) / s, (m[0][2] + m[2][0]) / s, (m[2][1] - m[1][2]) / s}
+	case m[1][1] > m[2][2]:
+		s := 2 * sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q = Quaternion[T]{(m[0][1] + m[1][0]) / s, s / 4, (m[1][2] + m[2][1]) / s, (m[0][2] - m[2][0]) / s}
+	default:
+		s := 2 * sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q = Quaternion[T]{(m[0][2] + m[2][0]) / s, (m[1][2] + m[2][1]) / s, s / 4, (m[1][0] - m[0][1]) / s}
+	}
+	return q.Normalize()
+}
+
+//-----------------------------------------------------------------------------
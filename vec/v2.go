@@ -1,19 +1,141 @@
 package vec
 
-import (
-//"math"
-)
+// 2d vector
+type Vec2[T Number] [2]T
 
-type V2 [2]float32
+// Return a + b
+func (a Vec2[T]) Add(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		a[0] + b[0],
+		a[1] + b[1],
+	}
+}
 
 // Return a - b
-func (a V2) Sub(b V2) V2 {
-	return V2{
+func (a Vec2[T]) Sub(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
 		a[0] - b[0],
 		a[1] - b[1],
 	}
 }
 
-func (a V2) Dot(b V2) float32 {
-	return a[0]*b[0] + a[1]*b[1]
+// Return the component-wise product a * b
+func (a Vec2[T]) Mul(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		a[0] * b[0],
+		a[1] * b[1],
+	}
+}
+
+// Return the component-wise quotient a / b
+func (a Vec2[T]) Div(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		a[0] / b[0],
+		a[1] / b[1],
+	}
+}
+
+// Return a * k
+func (a Vec2[T]) Scale(k T) Vec2[T] {
+	return Vec2[T]{
+		a[0] * k,
+		a[1] * k,
+	}
+}
+
+// Return -a
+func (a Vec2[T]) Neg() Vec2[T] {
+	return Vec2[T]{-a[0], -a[1]}
+}
+
+// Return the component-wise minimum of a and b
+func (a Vec2[T]) Min(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		min(a[0], b[0]),
+		min(a[1], b[1]),
+	}
+}
+
+// Return the component-wise maximum of a and b
+func (a Vec2[T]) Max(b Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		max(a[0], b[0]),
+		max(a[1], b[1]),
+	}
+}
+
+// Return the linear interpolation from a (t = 0) to b (t = 1)
+func (a Vec2[T]) Lerp(b Vec2[T], t T) Vec2[T] {
+	return Vec2[T]{
+		lerp(a[0], b[0], t),
+		lerp(a[1], b[1], t),
+	}
+}
+
+// Return a with each component clamped to [lo, hi]
+func (a Vec2[T]) Clamp(lo, hi Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		clamp(a[0], lo[0], hi[0]),
+		clamp(a[1], lo[1], hi[1]),
+	}
+}
+
+// Return the component-wise absolute value of a
+func (a Vec2[T]) Abs() Vec2[T] {
+	return Vec2[T]{abs(a[0]), abs(a[1])}
+}
+
+// return true if the vectors are equal
+func (a Vec2[T]) Equal(b Vec2[T]) bool {
+	return a == b
+}
+
+// return true if each component of a is within eps of b
+func (a Vec2[T]) Equal_Eps(b Vec2[T], eps T) bool {
+	return abs(a[0]-b[0]) <= eps &&
+		abs(a[1]-b[1]) <= eps
+}
+
+// Return the z component of the cross product of a and b
+func (a Vec2[T]) Cross(b Vec2[T]) T {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+// Return a.b
+func (a Vec2[T]) Dot(b Vec2[T]) T {
+	return (a[0] * b[0]) +
+		(a[1] * b[1])
+}
+
+// Return the Euclidean length of a
+func (a Vec2[T]) Length() T {
+	return sqrt(a.Dot(a))
+}
+
+// Normalize a
+func (a Vec2[T]) Normalize() Vec2[T] {
+	l := a.Length()
+	if l == 0 {
+		return a
+	}
+	return Vec2[T]{
+		a[0] / l,
+		a[1] / l,
+	}
+}
+
+// Return the reflection of a about the unit normal n
+func (a Vec2[T]) Reflect(n Vec2[T]) Vec2[T] {
+	return a.Sub(n.Scale(2 * a.Dot(n)))
+}
+
+// Return the refraction of the unit vector a through a surface with unit normal n.
+// eta is the ratio of the refractive indices. Returns zero for total internal reflection.
+func (a Vec2[T]) Refract(n Vec2[T], eta T) Vec2[T] {
+	d := a.Dot(n)
+	k := 1 - eta*eta*(1-d*d)
+	if k < 0 {
+		return Vec2[T]{}
+	}
+	return a.Scale(eta).Sub(n.Scale(eta*d + sqrt(k)))
 }
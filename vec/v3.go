@@ -0,0 +1,167 @@
+package vec
+
+// 3d vector
+type Vec3[T Number] [3]T
+
+// Return a + b
+func (a Vec3[T]) Add(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[0] + b[0],
+		a[1] + b[1],
+		a[2] + b[2],
+	}
+}
+
+// Return a - b
+func (a Vec3[T]) Sub(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[0] - b[0],
+		a[1] - b[1],
+		a[2] - b[2],
+	}
+}
+
+// Return the component-wise product a * b
+func (a Vec3[T]) Mul(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[0] * b[0],
+		a[1] * b[1],
+		a[2] * b[2],
+	}
+}
+
+// Return the component-wise quotient a / b
+func (a Vec3[T]) Div(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[0] / b[0],
+		a[1] / b[1],
+		a[2] / b[2],
+	}
+}
+
+// Return a * k
+func (a Vec3[T]) Scale(k T) Vec3[T] {
+	return Vec3[T]{
+		a[0] * k,
+		a[1] * k,
+		a[2] * k,
+	}
+}
+
+// Return -a
+func (a Vec3[T]) Neg() Vec3[T] {
+	return Vec3[T]{-a[0], -a[1], -a[2]}
+}
+
+// Return the component-wise minimum of a and b
+func (a Vec3[T]) Min(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		min(a[0], b[0]),
+		min(a[1], b[1]),
+		min(a[2], b[2]),
+	}
+}
+
+// Return the component-wise maximum of a and b
+func (a Vec3[T]) Max(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		max(a[0], b[0]),
+		max(a[1], b[1]),
+		max(a[2], b[2]),
+	}
+}
+
+// Return the linear interpolation from a (t = 0) to b (t = 1)
+func (a Vec3[T]) Lerp(b Vec3[T], t T) Vec3[T] {
+	return Vec3[T]{
+		lerp(a[0], b[0], t),
+		lerp(a[1], b[1], t),
+		lerp(a[2], b[2], t),
+	}
+}
+
+// Return a with each component clamped to [lo, hi]
+func (a Vec3[T]) Clamp(lo, hi Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		clamp(a[0], lo[0], hi[0]),
+		clamp(a[1], lo[1], hi[1]),
+		clamp(a[2], lo[2], hi[2]),
+	}
+}
+
+// Return the component-wise absolute value of a
+func (a Vec3[T]) Abs() Vec3[T] {
+	return Vec3[T]{abs(a[0]), abs(a[1]), abs(a[2])}
+}
+
+// return true if the vectors are equal
+func (a Vec3[T]) Equal(b Vec3[T]) bool {
+	return a == b
+}
+
+// return true if each component of a is within eps of b
+func (a Vec3[T]) Equal_Eps(b Vec3[T], eps T) bool {
+	return abs(a[0]-b[0]) <= eps &&
+		abs(a[1]-b[1]) <= eps &&
+		abs(a[2]-b[2]) <= eps
+}
+
+// Return a x b
+func (a Vec3[T]) Cross(b Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// Return a.b
+func (a Vec3[T]) Dot(b Vec3[T]) T {
+	return (a[0] * b[0]) +
+		(a[1] * b[1]) +
+		(a[2] * b[2])
+}
+
+// Return the Euclidean length of a
+func (a Vec3[T]) Length() T {
+	return sqrt(a.Dot(a))
+}
+
+// Normalize a
+func (a Vec3[T]) Normalize() Vec3[T] {
+	l := a.Length()
+	if l == 0 {
+		return a
+	}
+	return Vec3[T]{
+		a[0] / l,
+		a[1] / l,
+		a[2] / l,
+	}
+}
+
+// Return the reflection of a about the unit normal n
+func (a Vec3[T]) Reflect(n Vec3[T]) Vec3[T] {
+	return a.Sub(n.Scale(2 * a.Dot(n)))
+}
+
+// Return the refraction of the unit vector a through a surface with unit normal n.
+// eta is the ratio of the refractive indices. Returns zero for total internal reflection.
+func (a Vec3[T]) Refract(n Vec3[T], eta T) Vec3[T] {
+	d := a.Dot(n)
+	k := 1 - eta*eta*(1-d*d)
+	if k < 0 {
+		return Vec3[T]{}
+	}
+	return a.Scale(eta).Sub(n.Scale(eta*d + sqrt(k)))
+}
+
+// Return the x, y components of a
+func (a Vec3[T]) ToV2() Vec2[T] {
+	return Vec2[T]{a[0], a[1]}
+}
+
+// Return a as a homogeneous vector with the given w
+func (a Vec3[T]) ToV4(w T) Vec4[T] {
+	return Vec4[T]{a[0], a[1], a[2], w}
+}
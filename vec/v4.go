@@ -0,0 +1,170 @@
+package vec
+
+// 4d homogeneous vector
+type Vec4[T Number] [4]T
+
+// Return a + b
+func (a Vec4[T]) Add(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		a[0] + b[0],
+		a[1] + b[1],
+		a[2] + b[2],
+		a[3] + b[3],
+	}
+}
+
+// Return a - b
+func (a Vec4[T]) Sub(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		a[0] - b[0],
+		a[1] - b[1],
+		a[2] - b[2],
+		a[3] - b[3],
+	}
+}
+
+// Return the component-wise product a * b
+func (a Vec4[T]) Mul(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		a[0] * b[0],
+		a[1] * b[1],
+		a[2] * b[2],
+		a[3] * b[3],
+	}
+}
+
+// Return the component-wise quotient a / b
+func (a Vec4[T]) Div(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		a[0] / b[0],
+		a[1] / b[1],
+		a[2] / b[2],
+		a[3] / b[3],
+	}
+}
+
+// Return a * k
+func (a Vec4[T]) Scale(k T) Vec4[T] {
+	return Vec4[T]{
+		a[0] * k,
+		a[1] * k,
+		a[2] * k,
+		a[3] * k,
+	}
+}
+
+// Return -a
+func (a Vec4[T]) Neg() Vec4[T] {
+	return Vec4[T]{-a[0], -a[1], -a[2], -a[3]}
+}
+
+// Return the component-wise minimum of a and b
+func (a Vec4[T]) Min(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		min(a[0], b[0]),
+		min(a[1], b[1]),
+		min(a[2], b[2]),
+		min(a[3], b[3]),
+	}
+}
+
+// Return the component-wise maximum of a and b
+func (a Vec4[T]) Max(b Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		max(a[0], b[0]),
+		max(a[1], b[1]),
+		max(a[2], b[2]),
+		max(a[3], b[3]),
+	}
+}
+
+// Return the linear interpolation from a (t = 0) to b (t = 1)
+func (a Vec4[T]) Lerp(b Vec4[T], t T) Vec4[T] {
+	return Vec4[T]{
+		lerp(a[0], b[0], t),
+		lerp(a[1], b[1], t),
+		lerp(a[2], b[2], t),
+		lerp(a[3], b[3], t),
+	}
+}
+
+// Return a with each component clamped to [lo, hi]
+func (a Vec4[T]) Clamp(lo, hi Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		clamp(a[0], lo[0], hi[0]),
+		clamp(a[1], lo[1], hi[1]),
+		clamp(a[2], lo[2], hi[2]),
+		clamp(a[3], lo[3], hi[3]),
+	}
+}
+
+// Return the component-wise absolute value of a
+func (a Vec4[T]) Abs() Vec4[T] {
+	return Vec4[T]{abs(a[0]), abs(a[1]), abs(a[2]), abs(a[3])}
+}
+
+// return true if the vectors are equal
+func (a Vec4[T]) Equal(b Vec4[T]) bool {
+	return a == b
+}
+
+// return true if each component of a is within eps of b
+func (a Vec4[T]) Equal_Eps(b Vec4[T], eps T) bool {
+	return abs(a[0]-b[0]) <= eps &&
+		abs(a[1]-b[1]) <= eps &&
+		abs(a[2]-b[2]) <= eps &&
+		abs(a[3]-b[3]) <= eps
+}
+
+// Return a.b
+func (a Vec4[T]) Dot(b Vec4[T]) T {
+	return (a[0] * b[0]) +
+		(a[1] * b[1]) +
+		(a[2] * b[2]) +
+		(a[3] * b[3])
+}
+
+// Return the Euclidean length of a
+func (a Vec4[T]) Length() T {
+	return sqrt(a.Dot(a))
+}
+
+// Normalize a
+func (a Vec4[T]) Normalize() Vec4[T] {
+	l := a.Length()
+	if l == 0 {
+		return a
+	}
+	return Vec4[T]{
+		a[0] / l,
+		a[1] / l,
+		a[2] / l,
+		a[3] / l,
+	}
+}
+
+// Return the reflection of a about the unit normal n
+func (a Vec4[T]) Reflect(n Vec4[T]) Vec4[T] {
+	return a.Sub(n.Scale(2 * a.Dot(n)))
+}
+
+// Return the refraction of the unit vector a through a surface with unit normal n.
+// eta is the ratio of the refractive indices. Returns zero for total internal reflection.
+func (a Vec4[T]) Refract(n Vec4[T], eta T) Vec4[T] {
+	d := a.Dot(n)
+	k := 1 - eta*eta*(1-d*d)
+	if k < 0 {
+		return Vec4[T]{}
+	}
+	return a.Scale(eta).Sub(n.Scale(eta*d + sqrt(k)))
+}
+
+// Return the x, y, z components of a (w is dropped)
+func (a Vec4[T]) ToV3() Vec3[T] {
+	return Vec3[T]{a[0], a[1], a[2]}
+}
+
+// Return the x, y, z components of a divided by w
+func (a Vec4[T]) Divide() Vec3[T] {
+	return Vec3[T]{a[0] / a[3], a[1] / a[3], a[2] / a[3]}
+}
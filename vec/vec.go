@@ -0,0 +1,110 @@
+//-----------------------------------------------------------------------------
+/*
+
+Vectors and Matrices
+
+The vector types are generic over their component type. float32 is used for
+fast previews and float64 for precise offline renders. The integer vectors
+are used for pixel coordinates.
+
+Every vector type has the same set of operations. Operations that need a
+square root (Length, Normalize, Refract) are done in float64 and converted
+back to the component type.
+
+*/
+//-----------------------------------------------------------------------------
+
+package vec
+
+import (
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// Float is the component type for float vectors and matrices
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is the component type for vectors
+type Number interface {
+	~int | ~float32 | ~float64
+}
+
+// float32 types
+type V2 = Vec2[float32]
+type V3 = Vec3[float32]
+type V4 = Vec4[float32]
+type M3 = Mat3[float32]
+type M4 = Mat4[float32]
+
+// V3f is the same as V3
+type V3f = V3
+
+// float64 types
+type V2d = Vec2[float64]
+type V3d = Vec3[float64]
+type V4d = Vec4[float64]
+type M3d = Mat3[float64]
+type M4d = Mat4[float64]
+
+// integer types
+type V2i = Vec2[int]
+type V3i = Vec3[int]
+
+//-----------------------------------------------------------------------------
+// scalar operations
+
+func sqrt[T Number](x T) T {
+	return T(math.Sqrt(float64(x)))
+}
+
+func abs[T Number](x T) T {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func min[T Number](a, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max[T Number](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clamp[T Number](x, lo, hi T) T {
+	return max(lo, min(x, hi))
+}
+
+func lerp[T Number](a, b, t T) T {
+	return a + (b-a)*t
+}
+
+//-----------------------------------------------------------------------------
+// conversions
+
+// Convert2 converts the components of a 2d vector
+func Convert2[U, T Number](a Vec2[T]) Vec2[U] {
+	return Vec2[U]{U(a[0]), U(a[1])}
+}
+
+// Convert3 converts the components of a 3d vector
+func Convert3[U, T Number](a Vec3[T]) Vec3[U] {
+	return Vec3[U]{U(a[0]), U(a[1]), U(a[2])}
+}
+
+// Convert4 converts the components of a 4d vector
+func Convert4[U, T Number](a Vec4[T]) Vec4[U] {
+	return Vec4[U]{U(a[0]), U(a[1]), U(a[2]), U(a[3])}
+}
+
+//-----------------------------------------------------------------------------
@@ -10,7 +10,7 @@ func Test_Ops(t *testing.T) {
 	b := V3f{4, 5, 6}
 
 	good_sum := V3f{5, 7, 9}
-	if a.Sum(b) != good_sum {
+	if a.Add(b) != good_sum {
 		t.Error("FAIL")
 	}
 
@@ -46,3 +46,286 @@ func Test_Ops(t *testing.T) {
 	}
 
 }
+
+// return true if the matrices are equal within tolerance
+func m4_equal(a, b M4, tolerance float32) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if float32(math.Abs(float64(a[i][j]-b[i][j]))) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// return true if the vectors are equal within tolerance
+func v3_equal(a, b V3, tolerance float32) bool {
+	return a.Sub(b).Length() <= tolerance
+}
+
+func Test_M3(t *testing.T) {
+	a := M3{{2, 1, 0}, {1, 1, 0}, {0, 0, 1}}
+	if a.Det() != 1 {
+		t.Error("FAIL")
+	}
+	inv, ok := a.Inverse()
+	if !ok || a.Mul(inv) != Identity_M3() {
+		t.Error("FAIL")
+	}
+	if a.Transpose().Transpose() != a || a.Transpose()[0][1] != 1 {
+		t.Error("FAIL")
+	}
+	if a.MulV(V3{1, 2, 3}) != (V3{4, 3, 3}) {
+		t.Error("FAIL")
+	}
+	if _, ok := (M3{{1, 2, 3}, {2, 4, 6}, {0, 0, 1}}).Inverse(); ok {
+		t.Error("FAIL")
+	}
+}
+
+func Test_M4(t *testing.T) {
+	a := M4{{2, 0, 1, 3}, {1, 3, 2, 0}, {1, 1, 2, 1}, {0, 2, 0, 1}}
+	b := Translate(V3{1, 2, 3}).Mul(Rotate(V3{1, 1, 0}, 0.7)).Mul(Scale(V3{2, 3, 4}))
+	for _, m := range []M4{a, b, Identity_M4()} {
+		inv, ok := m.Inverse()
+		if !ok || !m4_equal(m.Mul(inv), Identity_M4(), 1e-5) {
+			t.Error("FAIL")
+		}
+	}
+	if a.Det() != 12 {
+		t.Errorf("FAIL %f", a.Det())
+	}
+	if a.Transpose()[3][0] != 3 || a.Transpose().Transpose() != a {
+		t.Error("FAIL")
+	}
+	if _, ok := (M4{{1, 2, 3, 4}, {2, 4, 6, 8}}).Inverse(); ok {
+		t.Error("FAIL")
+	}
+
+	// transforms
+	if Translate(V3{1, 2, 3}).Mul_Point(V3{1, 1, 1}) != (V3{2, 3, 4}) {
+		t.Error("FAIL")
+	}
+	if Translate(V3{1, 2, 3}).Mul_Dir(V3{1, 1, 1}) != (V3{1, 1, 1}) {
+		t.Error("FAIL")
+	}
+	if !v3_equal(Rotate(V3{0, 0, 1}, math.Pi/2).Mul_Point(V3{1, 0, 0}), V3{0, 1, 0}, 1e-6) {
+		t.Error("FAIL")
+	}
+	n := Scale(V3{1, 2, 1}).Normal_Matrix().MulV(V3{0, 1, 0})
+	if n != (V3{0, 0.5, 0}) {
+		t.Error("FAIL")
+	}
+
+	// camera at +z looking at the origin
+	view := LookAt(V3{0, 0, 5}, V3{0, 0, 0}, V3{0, 1, 0})
+	if !v3_equal(view.Mul_Point(V3{1, 2, 0}), V3{1, 2, -5}, 1e-6) {
+		t.Error("FAIL")
+	}
+	// camera on +x, the origin is 3 units in front
+	view = LookAt(V3{3, 0, 0}, V3{0, 0, 0}, V3{0, 1, 0})
+	if !v3_equal(view.Mul_Point(V3{0, 0, 0}), V3{0, 0, -3}, 1e-6) {
+		t.Error("FAIL")
+	}
+
+	// near and far planes map to -1 and 1
+	proj := Perspective[float32](math.Pi/2, 2, 1, 10)
+	if !v3_equal(proj.Mul_Point(V3{2, 1, -1}), V3{1, 1, -1}, 1e-6) {
+		t.Error("FAIL")
+	}
+	if !v3_equal(proj.Mul_Point(V3{0, 0, -10}), V3{0, 0, 1}, 1e-6) {
+		t.Error("FAIL")
+	}
+	ortho := Orthographic[float32](-2, 2, -1, 1, 1, 3)
+	if !v3_equal(ortho.Mul_Point(V3{2, -1, -1}), V3{1, -1, -1}, 1e-6) {
+		t.Error("FAIL")
+	}
+	if !v3_equal(ortho.Mul_Point(V3{0, 0, -3}), V3{0, 0, 1}, 1e-6) {
+		t.Error("FAIL")
+	}
+
+	vp := Viewport[float32](0, 0, 640, 480)
+	if vp.Mul_Point(V3{-1, -1, -1}) != (V3{0, 0, 0}) || vp.Mul_Point(V3{1, 1, 1}) != (V3{640, 480, 1}) {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Generic_Ops(t *testing.T) {
+	a := V3d{1, -2, 3}
+	b := V3d{4, 5, -6}
+	if a.Add(b) != (V3d{5, 3, -3}) || a.Sub(b) != (V3d{-3, -7, 9}) {
+		t.Error("FAIL")
+	}
+	if a.Mul(b) != (V3d{4, -10, -18}) || b.Div(V3d{2, 5, -3}) != (V3d{2, 1, 2}) {
+		t.Error("FAIL")
+	}
+	if a.Neg() != (V3d{-1, 2, -3}) || a.Abs() != (V3d{1, 2, 3}) {
+		t.Error("FAIL")
+	}
+	if a.Min(b) != (V3d{1, -2, -6}) || a.Max(b) != (V3d{4, 5, 3}) {
+		t.Error("FAIL")
+	}
+	if a.Lerp(b, 0.5) != (V3d{2.5, 1.5, -1.5}) {
+		t.Error("FAIL")
+	}
+	if a.Clamp(V3d{0, 0, 0}, V3d{2, 2, 2}) != (V3d{1, 0, 2}) {
+		t.Error("FAIL")
+	}
+	if !a.Equal_Eps(V3d{1.001, -2, 2.999}, 0.01) || a.Equal_Eps(V3d{1.1, -2, 3}, 0.01) {
+		t.Error("FAIL")
+	}
+
+	// float64 keeps precision that float32 loses
+	if (V3d{1e-9, 0, 0}).Add(V3d{1, 0, 0})[0] == 1 || (V3{1e-9, 0, 0}.Add(V3{1, 0, 0}))[0] != 1 {
+		t.Error("FAIL")
+	}
+	if Convert3[float32](V3d{1, 2, 3}) != (V3{1, 2, 3}) {
+		t.Error("FAIL")
+	}
+
+	// all sizes have the same ops
+	if (V2{1, 2}).Lerp(V2{3, 4}, 0.5) != (V2{2, 3}) || (V4{1, 2, 3, 4}).Neg() != (V4{-1, -2, -3, -4}) {
+		t.Error("FAIL")
+	}
+	if (V2{3, 4}).Length() != 5 || (V4d{0, 0, 3, 4}).Normalize() != (V4d{0, 0, 0.6, 0.8}) {
+		t.Error("FAIL")
+	}
+	if (V2{1, 0}).Cross(V2{0, 1}) != 1 {
+		t.Error("FAIL")
+	}
+	if (V2i{3, -4}).Abs() != (V2i{3, 4}) || (V2i{1, 8}).Max(V2i{5, 2}) != (V2i{5, 8}) {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Reflect_Refract(t *testing.T) {
+	n := V3d{0, 1, 0}
+	d := V3d{1, -1, 0}.Normalize()
+	if !d.Reflect(n).Equal_Eps(V3d{1, 1, 0}.Normalize(), 1e-12) {
+		t.Error("FAIL")
+	}
+	// no bending for eta = 1
+	if !d.Refract(n, 1).Equal_Eps(d, 1e-12) {
+		t.Error("FAIL")
+	}
+	// snell's law: sin(t) = eta * sin(i)
+	r := d.Refract(n, 1/1.5)
+	if math.Abs(r.Length()-1) > 1e-12 || math.Abs(r[0]-math.Sqrt(0.5)/1.5) > 1e-12 || r[1] >= 0 {
+		t.Error("FAIL")
+	}
+	// total internal reflection
+	if d.Refract(n, 1.5) != (V3d{}) {
+		t.Error("FAIL")
+	}
+	// float32 matches float64
+	if !Convert3[float64](Convert3[float32](d).Refract(V3{0, 1, 0}, 1/1.5)).Equal_Eps(r, 1e-6) {
+		t.Error("FAIL")
+	}
+}
+
+func Test_M4d(t *testing.T) {
+	m := Translate(V3d{1, 2, 3}).Mul(Rotate(V3d{0, 1, 1}, 1.1)).Mul(Scale(V3d{2, 3, 4}))
+	inv, ok := m.Inverse()
+	if !ok {
+		t.Fatal("FAIL")
+	}
+	p := V3d{0.1, 0.2, 0.3}
+	if !inv.Mul_Point(m.Mul_Point(p)).Equal_Eps(p, 1e-12) {
+		t.Error("FAIL")
+	}
+	if Identity_Mat4[float64]().Mul(m) != m || Identity_M4().Mul(M4{}) != (M4{}) {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Quat(t *testing.T) {
+	// axis angle matches the rotation matrix
+	axis := V3d{1, 2, 3}
+	q := Axis_Angle(axis, 0.8)
+	p := V3d{0.5, -1, 2}
+	if !q.Rotate(p).Equal_Eps(Rotate(axis, 0.8).Mul_Point(p), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !q.M4().Mul_Point(p).Equal_Eps(q.Rotate(p), 1e-12) {
+		t.Error("FAIL")
+	}
+	a, angle := q.Axis_Angle()
+	if !a.Equal_Eps(axis.Normalize(), 1e-12) || math.Abs(angle-0.8) > 1e-12 {
+		t.Error("FAIL")
+	}
+
+	// matrix round trip for all branches
+	for _, x := range []Quatd{q, Axis_Angle(V3d{1, 0, 0}, 3), Axis_Angle(V3d{0, 1, 0}, 3), Axis_Angle(V3d{0, 0, 1}, 3)} {
+		y := From_M4(x.M4())
+		if !y.Equal_Eps(x, 1e-12) && !y.Equal_Eps(x.Neg(), 1e-12) {
+			t.Error("FAIL")
+		}
+	}
+
+	// composition order matches matrices
+	r := Axis_Angle(V3d{0, 1, 0}, 0.3)
+	if !q.Mul(r).Rotate(p).Equal_Eps(q.M4().Mul(r.M4()).Mul_Point(p), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !q.Mul(q.Inverse()).Equal_Eps(Identity_Quaternion[float64](), 1e-12) {
+		t.Error("FAIL")
+	}
+
+	// euler angles
+	e := V3d{0.1, 0.2, 0.3}
+	rx := Rotate(V3d{1, 0, 0}, e[0])
+	ry := Rotate(V3d{0, 1, 0}, e[1])
+	rz := Rotate(V3d{0, 0, 1}, e[2])
+	if !Euler(e, XYZ).Rotate(p).Equal_Eps(rz.Mul(ry).Mul(rx).Mul_Point(p), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !Euler(e, ZYX).Rotate(p).Equal_Eps(rx.Mul(ry).Mul(rz).Mul_Point(p), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !Euler(e, YZX).Rotate(p).Equal_Eps(rx.Mul(rz).Mul(ry).Mul_Point(p), 1e-12) {
+		t.Error("FAIL")
+	}
+
+	// interpolation
+	q0 := Identity_Quaternion[float64]()
+	q1 := Axis_Angle(V3d{0, 0, 1}, 2)
+	if !q0.Slerp(q1, 0.25).Equal_Eps(Axis_Angle(V3d{0, 0, 1}, 0.5), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !q0.Slerp(q1.Neg(), 0.5).Equal_Eps(Axis_Angle(V3d{0, 0, 1}, 1), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !q0.Nlerp(q1, 0.5).Equal_Eps(Axis_Angle(V3d{0, 0, 1}, 1), 1e-12) {
+		t.Error("FAIL")
+	}
+	if !q1.Slerp(q1, 0.5).Equal_Eps(q1, 1e-12) {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Arcball(t *testing.T) {
+	a := New_Arcball(V2d{100, 100}, 50)
+	// dragging right from the center rotates about +y
+	a.Drag(V2d{100, 100}, V2d{50, 0})
+	if !a.Orientation.Equal_Eps(Axis_Angle(V3d{0, 1, 0}, math.Pi/2), 1e-12) {
+		t.Error("FAIL")
+	}
+	// dragging up rotates about -x
+	q := a.Rotation(V2d{100, 100}, V2d{100, 125})
+	if !q.Equal_Eps(Axis_Angle(V3d{-1, 0, 0}, math.Pi/6), 1e-12) {
+		t.Error("FAIL")
+	}
+	// dragging around the rim rotates about the view axis
+	q = a.Rotation(V2d{200, 100}, V2d{100, 200})
+	if !q.Equal_Eps(Axis_Angle(V3d{0, 0, 1}, math.Pi/2), 1e-12) {
+		t.Error("FAIL")
+	}
+	// a drag and its reverse cancel
+	b := New_Arcball(V2d{0, 0}, 1)
+	b.Drag(V2d{0.1, 0.2}, V2d{0.3, -0.1})
+	b.Drag(V2d{0.4, 0.1}, V2d{-0.3, 0.1})
+	if !b.Orientation.Equal_Eps(Identity_Quaternion[float64](), 1e-12) {
+		t.Error("FAIL")
+	}
+}
@@ -0,0 +1,207 @@
+//-----------------------------------------------------------------------------
+/*
+
+Named Objects and Groups
+
+An "o" statement names the object for the faces that follow it.
+A "g" statement sets the group (or groups) for the faces that follow it.
+A face belongs to at most one object and to zero or more groups.
+The groups for a face are stored as an index to a set of group names.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+//-----------------------------------------------------------------------------
+
+// return the index of a name in a list (0 if not found)
+func find_name(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// add an object name (if needed), return its index
+func (o *Object) Add_Object_Name(name string) int {
+	if i := o.Find_Object(name); i != 0 {
+		return i
+	}
+	o.o_names = append(o.o_names, name)
+	return len(o.o_names)
+}
+
+// add a group name (if needed), return its index
+func (o *Object) Add_Group_Name(name string) int {
+	if i := o.Find_Group(name); i != 0 {
+		return i
+	}
+	o.g_names = append(o.g_names, name)
+	return len(o.g_names)
+}
+
+// add a set of group name indices, return its index (0 for an empty set)
+func (o *Object) Add_Group_Set(set []int) int {
+	if len(set) == 0 {
+		return 0
+	}
+	o.g_sets = append(o.g_sets, set)
+	return len(o.g_sets)
+}
+
+// return the set of group name indices for an element
+func (o *Object) element_groups(e *Element) []int {
+	if e.groups == 0 {
+		return nil
+	}
+	return o.g_sets[e.groups-1]
+}
+
+// return the set of group name indices for the i-th face
+func (o *Object) group_set(i int) []int {
+	return o.element_groups(&o.f_list[i].Element)
+}
+
+// return the index of the named object (0 if not found)
+func (o *Object) Find_Object(name string) int {
+	return find_name(o.o_names, name)
+}
+
+// return the index of the named group (0 if not found)
+func (o *Object) Find_Group(name string) int {
+	return find_name(o.g_names, name)
+}
+
+func (o *Object) Len_Object() int {
+	return len(o.o_names)
+}
+
+func (o *Object) Len_Group() int {
+	return len(o.g_names)
+}
+
+// return the name of the i-th object
+func (o *Object) Object_Name(i int) string {
+	return o.o_names[i]
+}
+
+// return the name of the i-th group
+func (o *Object) Group_Name(i int) string {
+	return o.g_names[i]
+}
+
+// return the object name for the i-th face ("" for none)
+func (o *Object) Get_Object(i int) string {
+	k := o.f_list[i].object
+	if k == 0 {
+		return ""
+	}
+	return o.o_names[k-1]
+}
+
+// return the group names for the i-th face
+func (o *Object) Get_Groups(i int) []string {
+	var names []string
+	for _, k := range o.group_set(i) {
+		names = append(names, o.g_names[k-1])
+	}
+	return names
+}
+
+//-----------------------------------------------------------------------------
+// face iteration
+
+// return the indices of the faces in the named object
+func (o *Object) Object_Faces(name string) []int {
+	k := o.Find_Object(name)
+	if k == 0 {
+		return nil
+	}
+	var faces []int
+	for i, f := range o.f_list {
+		if f.object == k {
+			faces = append(faces, i)
+		}
+	}
+	return faces
+}
+
+// return the indices of the faces in the named group
+func (o *Object) Group_Faces(name string) []int {
+	k := o.Find_Group(name)
+	if k == 0 {
+		return nil
+	}
+	var faces []int
+	for i := range o.f_list {
+		for _, g := range o.group_set(i) {
+			if g == k {
+				faces = append(faces, i)
+				break
+			}
+		}
+	}
+	return faces
+}
+
+//-----------------------------------------------------------------------------
+// subsets
+
+// Subset returns an object with the selected faces.
+// The vertex, line, point and material data is shared with the original object.
+func (o *Object) Subset(faces []int) *Object {
+	s := *o
+	s.f_list = make([]Face, len(faces))
+	for i, k := range faces {
+		s.f_list[i] = o.f_list[k]
+	}
+	return &s
+}
+
+// return an object with the faces, lines and points for which f(element) is true
+func (o *Object) select_elements(f func(e *Element) bool) *Object {
+	var faces []int
+	for i := range o.f_list {
+		if f(&o.f_list[i].Element) {
+			faces = append(faces, i)
+		}
+	}
+	s := o.Subset(faces)
+	s.l_list = select_elements(o.l_list, f)
+	s.p_list = select_elements(o.p_list, f)
+	return s
+}
+
+// Select_Objects returns an object with the faces, lines and points from the named objects
+func (o *Object) Select_Objects(names ...string) *Object {
+	want := make(map[int]bool)
+	for _, name := range names {
+		want[o.Find_Object(name)] = true
+	}
+	delete(want, 0)
+	return o.select_elements(func(e *Element) bool {
+		return want[e.object]
+	})
+}
+
+// Select_Groups returns an object with the faces, lines and points from the named groups
+func (o *Object) Select_Groups(names ...string) *Object {
+	want := make(map[int]bool)
+	for _, name := range names {
+		want[o.Find_Group(name)] = true
+	}
+	delete(want, 0)
+	return o.select_elements(func(e *Element) bool {
+		for _, g := range o.element_groups(e) {
+			if want[g] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+//-----------------------------------------------------------------------------
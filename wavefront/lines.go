@@ -0,0 +1,118 @@
+//-----------------------------------------------------------------------------
+/*
+
+Line and Point Elements
+
+An "l" statement is a polyline through 2 or more vertices, each given as a
+geometric vertex index with an optional texture vertex index (v or v/vt).
+A "p" statement is a set of 1 or more points given as geometric vertex indices.
+Lines and points have the object, groups and material of the faces around
+them but no smoothing group.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+//-----------------------------------------------------------------------------
+// lines
+
+func (o *Object) Len_L() int {
+	return len(o.l_list)
+}
+
+// return the number of vertices in the i-th line
+func (o *Object) Len_LV(i int) int {
+	return o.l_list[i].n
+}
+
+// return the elements of the i-th line
+func (o *Object) line_elem(i int) []F_elem {
+	l := &o.l_list[i]
+	return o.l_elem[l.elem : l.elem+l.n]
+}
+
+// return the j-th vertex from the i-th line
+func (o *Object) Get_LV(i, j int) V_elem {
+	x := o.v_list[(o.line_elem(i)[j].v-1)*v_stride:]
+	return V_elem{x: [3]float32{x[0], x[1], x[2]}, w: x[3]}
+}
+
+// return the j-th texture vertex from the i-th line (nil for none)
+func (o *Object) Get_LVT(i, j int) *VT_elem {
+	k := o.line_elem(i)[j].vt
+	if k == 0 {
+		return nil
+	}
+	x := o.vt_list[(k-1)*vt_stride:]
+	return &VT_elem{x: [2]float32{x[0], x[1]}, w: x[2]}
+}
+
+// return the material for the i-th line (nil for none)
+func (o *Object) Get_L_Material(i int) *Material {
+	return o.element_material(&o.l_list[i])
+}
+
+// add a line with the given elements
+func (o *Object) Add_L(elem []F_elem) {
+	o.l_list = append(o.l_list, Element{elem: len(o.l_elem), n: len(elem)})
+	o.l_elem = append(o.l_elem, elem...)
+}
+
+//-----------------------------------------------------------------------------
+// points
+
+func (o *Object) Len_P() int {
+	return len(o.p_list)
+}
+
+// return the number of vertices in the i-th point set
+func (o *Object) Len_PV(i int) int {
+	return o.p_list[i].n
+}
+
+// return the elements of the i-th point set
+func (o *Object) point_elem(i int) []F_elem {
+	p := &o.p_list[i]
+	return o.p_elem[p.elem : p.elem+p.n]
+}
+
+// return the j-th vertex from the i-th point set
+func (o *Object) Get_PV(i, j int) V_elem {
+	x := o.v_list[(o.point_elem(i)[j].v-1)*v_stride:]
+	return V_elem{x: [3]float32{x[0], x[1], x[2]}, w: x[3]}
+}
+
+// return the material for the i-th point set (nil for none)
+func (o *Object) Get_P_Material(i int) *Material {
+	return o.element_material(&o.p_list[i])
+}
+
+// add a point set with the given elements
+func (o *Object) Add_P(elem []F_elem) {
+	o.p_list = append(o.p_list, Element{elem: len(o.p_elem), n: len(elem)})
+	o.p_elem = append(o.p_elem, elem...)
+}
+
+//-----------------------------------------------------------------------------
+
+// return the material for an element (nil for none)
+func (o *Object) element_material(e *Element) *Material {
+	if e.material == 0 {
+		return nil
+	}
+	return o.mtl_list[e.material-1]
+}
+
+// return the elements for which f(element) is true
+func select_elements(list []Element, f func(e *Element) bool) []Element {
+	var s []Element
+	for i := range list {
+		if f(&list[i]) {
+			s = append(s, list[i])
+		}
+	}
+	return s
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,178 @@
+//-----------------------------------------------------------------------------
+/*
+
+Parallel OBJ Loading
+
+The file is split into chunks at line boundaries. A quick first pass counts
+the lines and vertices in each chunk so every chunk knows its starting line
+number and how many vertices precede it. The chunks are then parsed in
+parallel and merged in file order, so the result is identical to Read.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+// minimum chunk size in bytes
+const min_chunk_size = 64 * 1024
+
+// chunks per worker (smooths out uneven chunks)
+const chunks_per_worker = 4
+
+// line and vertex counts for a chunk
+type chunk_count struct {
+	lines, v, vt, vn int
+}
+
+// split data into chunks of about size bytes, ending on line boundaries
+func split_chunks(data []byte, size int) [][]byte {
+	var chunks [][]byte
+	for len(data) != 0 {
+		n := size
+		if n >= len(data) {
+			n = len(data)
+		} else if i := bytes.IndexByte(data[n:], '\n'); i >= 0 {
+			n += i + 1
+		} else {
+			n = len(data)
+		}
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return chunks
+}
+
+// return the statement keyword for a line
+func line_keyword(line []byte) []byte {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\r') {
+		i++
+	}
+	j := i
+	for j < len(line) && line[j] != ' ' && line[j] != '\t' && line[j] != '\r' && line[j] != '#' {
+		j++
+	}
+	return line[i:j]
+}
+
+// count the lines and vertex statements in a chunk
+func count_chunk(data []byte) chunk_count {
+	var c chunk_count
+	for len(data) != 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i]
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+		c.lines++
+		k := line_keyword(line)
+		if len(k) == 0 || k[0] != 'v' {
+			continue
+		}
+		switch string(k) {
+		case "v":
+			c.v++
+		case "vt":
+			c.vt++
+		case "vn":
+			c.vn++
+		}
+	}
+	return c
+}
+
+// run f(i) for i in [0, n) using the given number of goroutines
+func parallel(n, workers int, f func(i int)) {
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// parse the object data in parallel chunks of about chunk_size bytes
+func load(data []byte, filename string, chunk_size int, opts *Options) (*Object, error) {
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunks := split_chunks(data, chunk_size)
+
+	// first pass: count lines and vertices
+	counts := make([]chunk_count, len(chunks))
+	parallel(len(chunks), workers, func(i int) {
+		counts[i] = count_chunk(chunks[i])
+	})
+
+	// second pass: parse the chunks
+	parsers := make([]*parser, len(chunks))
+	var base chunk_count
+	for i := range chunks {
+		parsers[i] = new_parser(opts, filename, base.lines, base.v, base.vt, base.vn)
+		base.lines += counts[i].lines
+		base.v += counts[i].v
+		base.vt += counts[i].vt
+		base.vn += counts[i].vn
+	}
+	parallel(len(chunks), workers, func(i int) {
+		parsers[i].parse(bytes.NewReader(chunks[i]))
+	})
+
+	if len(parsers) == 0 {
+		parsers = append(parsers, new_parser(opts, filename, 0, 0, 0, 0))
+	}
+
+	obj, err := merge(parsers, opts)
+	if err != nil {
+		return nil, err
+	}
+	return obj.apply_options(opts), nil
+}
+
+// Load reads a wavefront object file using parallel parsing.
+// The result is the same as Read. Options may be nil.
+func Load(filename string, opts *Options) (*Object, error) {
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file_opts := file_options(filename, opts)
+	workers := file_opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunk_size := len(data) / (workers * chunks_per_worker)
+	if chunk_size < min_chunk_size {
+		chunk_size = min_chunk_size
+	}
+
+	return load(data, filename, chunk_size, file_opts)
+}
+
+//-----------------------------------------------------------------------------
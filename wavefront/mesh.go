@@ -0,0 +1,136 @@
+//-----------------------------------------------------------------------------
+/*
+
+Indexed Triangle Mesh
+
+A mesh is an exported, index based view of an object for rendering code.
+Each distinct v/vt/vn combination used by the faces becomes one mesh vertex,
+so positions, texture coordinates and normals share a single index.
+Polygons are triangulated. All mesh indices are 0-based with -1 for none.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import "github.com/deadsy/sw_render/vec"
+
+//-----------------------------------------------------------------------------
+
+// Mesh is an indexed triangle mesh
+type Mesh struct {
+	Positions   []vec.V3 // vertex positions
+	UVs         []vec.V2 // vertex texture coordinates (nil if no face has them)
+	Normals     []vec.V3 // vertex normals (nil if no face has them)
+	Faces       [][3]int // vertex indices for each triangle
+	Material    []int    // material index for each triangle (-1 for none)
+	Object      []int    // object name index for each triangle (-1 for none)
+	Groups      [][]int  // group name indices for each triangle
+	Smooth      []int    // smoothing group for each triangle (0 for off)
+	Materials   []*Material
+	Objects     []string // object names
+	Group_Names []string
+}
+
+// Mesh returns an indexed triangle mesh for the faces of the object.
+// The object is not modified.
+func (o *Object) Mesh() *Mesh {
+
+	m := &Mesh{
+		Materials:   o.mtl_list,
+		Objects:     o.o_names,
+		Group_Names: o.g_names,
+	}
+
+	// group sets with 0-based name indices
+	sets := make([][]int, len(o.g_sets)+1)
+	for i, set := range o.g_sets {
+		sets[i+1] = make([]int, len(set))
+		for j := range set {
+			sets[i+1][j] = set[j] - 1
+		}
+	}
+
+	// find the mesh vertex for each distinct face element
+	index := make(map[F_elem]int)
+	var elem []F_elem
+	has_vt := false
+	has_vn := false
+	vertex := func(e F_elem) int {
+		k, ok := index[e]
+		if !ok {
+			k = len(elem)
+			index[e] = k
+			elem = append(elem, e)
+			has_vt = has_vt || e.vt != 0
+			has_vn = has_vn || e.vn != 0
+		}
+		return k
+	}
+
+	for i, f := range o.f_list {
+		fe := o.face_elem(i)
+		tris := [][3]int{{0, 1, 2}}
+		if f.n != 3 {
+			p := make([]vec.V3, f.n)
+			for j := range fe {
+				p[j] = o.vertex(fe[j].v)
+			}
+			tris = triangulate(p)
+		}
+		for _, t := range tris {
+			m.Faces = append(m.Faces, [3]int{vertex(fe[t[0]]), vertex(fe[t[1]]), vertex(fe[t[2]])})
+			m.Material = append(m.Material, f.material-1)
+			m.Object = append(m.Object, f.object-1)
+			m.Groups = append(m.Groups, sets[f.groups])
+			m.Smooth = append(m.Smooth, f.smooth)
+		}
+	}
+
+	// vertex attributes
+	m.Positions = make([]vec.V3, len(elem))
+	if has_vt {
+		m.UVs = make([]vec.V2, len(elem))
+	}
+	if has_vn {
+		m.Normals = make([]vec.V3, len(elem))
+	}
+	for i, e := range elem {
+		m.Positions[i] = o.vertex(e.v)
+		if e.vt != 0 {
+			x := o.vt_list[(e.vt-1)*vt_stride:]
+			m.UVs[i] = vec.V2{x[0], x[1]}
+		}
+		if e.vn != 0 {
+			x := o.vn_list[(e.vn-1)*vn_stride:]
+			m.Normals[i] = vec.V3{x[0], x[1], x[2]}
+		}
+	}
+
+	return m
+}
+
+//-----------------------------------------------------------------------------
+// mesh accessors
+
+// return the positions of the i-th triangle
+func (m *Mesh) Triangle(i int) [3]vec.V3 {
+	f := m.Faces[i]
+	return [3]vec.V3{m.Positions[f[0]], m.Positions[f[1]], m.Positions[f[2]]}
+}
+
+// return the material of the i-th triangle (nil for none)
+func (m *Mesh) Get_Material(i int) *Material {
+	if m.Material[i] < 0 {
+		return nil
+	}
+	return m.Materials[m.Material[i]]
+}
+
+// return the face normal of the i-th triangle (counter clockwise winding)
+func (m *Mesh) Face_Normal(i int) vec.V3 {
+	p := m.Triangle(i)
+	return p[1].Sub(p[0]).Cross(p[2].Sub(p[0])).Normalize()
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,433 @@
+//-----------------------------------------------------------------------------
+/*
+
+Wavefront Material Library (MTL) Files
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Texture_Map is a texture map statement and its options
+type Texture_Map struct {
+	Filename   string  // filename as given in the mtl file
+	Path       string  // filename resolved relative to the mtl file
+	Blend_U    bool    // -blendu: horizontal texture blending
+	Blend_V    bool    // -blendv: vertical texture blending
+	Bump_Mult  float32 // -bm: bump multiplier
+	Boost      float32 // -boost: mip-map sharpness boost
+	Color_Corr bool    // -cc: color correction
+	Clamp      bool    // -clamp: clamp texture coordinates to 0..1
+	Channel    string  // -imfchan: r, g, b, m, l or z channel for scalar maps
+	Base       float32 // -mm: base value
+	Gain       float32 // -mm: gain value
+	Offset     vec.V3  // -o: texture origin offset
+	Scale      vec.V3  // -s: texture scale
+	Turbulence vec.V3  // -t: texture turbulence
+	Resolution int     // -texres: texture resolution
+	Reflection string  // -type: reflection map type (sphere, cube_top, ...)
+}
+
+// Material is a named material definition from an mtl file
+type Material struct {
+	Name      string
+	Ka        vec.V3  // ambient color
+	Kd        vec.V3  // diffuse color
+	Ks        vec.V3  // specular color
+	Ke        vec.V3  // emissive color
+	Tf        vec.V3  // transmission filter
+	Ns        float32 // specular exponent
+	Ni        float32 // optical density (index of refraction)
+	D         float32 // dissolve (1 = opaque)
+	Halo      bool    // -halo: dissolve depends on surface orientation
+	Sharpness float32 // reflection sharpness
+	Illum     int     // illumination model
+	Map_Ka    *Texture_Map
+	Map_Kd    *Texture_Map
+	Map_Ks    *Texture_Map
+	Map_Ns    *Texture_Map
+	Map_D     *Texture_Map
+	Map_Bump  *Texture_Map
+	Disp      *Texture_Map
+	Decal     *Texture_Map
+	Refl      []*Texture_Map
+}
+
+//-----------------------------------------------------------------------------
+
+// return a material with default values
+func new_material(name string) *Material {
+	return &Material{
+		Name:      name,
+		Ka:        vec.V3{0.2, 0.2, 0.2},
+		Kd:        vec.V3{0.8, 0.8, 0.8},
+		Ks:        vec.V3{1, 1, 1},
+		Tf:        vec.V3{1, 1, 1},
+		Ns:        0,
+		Ni:        1,
+		D:         1,
+		Sharpness: 60,
+		Illum:     2,
+	}
+}
+
+// return a texture map with default values
+func new_texture_map() *Texture_Map {
+	return &Texture_Map{
+		Blend_U:   true,
+		Blend_V:   true,
+		Bump_Mult: 1,
+		Channel:   "l",
+		Gain:      1,
+		Scale:     vec.V3{1, 1, 1},
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// parse a float argument
+func parse_float_arg(args []string, k int) (float32, error) {
+	var x [1]float32
+	err := parse_float_args(args, k, x[:])
+	return x[0], err
+}
+
+// parse an rgb color: "r [g b]" (a single value sets all components)
+func parse_color(args []string) (vec.V3, error) {
+	k := 0
+	if len(args) >= 1 && args[0] == "spectral" {
+		return vec.V3{}, bad_field(0, "spectral colors are not supported")
+	}
+	if len(args) >= 1 && args[0] == "xyz" {
+		k = 1
+	}
+	var x [3]float32
+	switch len(args) - k {
+	case 1:
+		if err := parse_float_args(args, k, x[:1]); err != nil {
+			return vec.V3{}, err
+		}
+		return vec.V3{x[0], x[0], x[0]}, nil
+	case 3:
+		if err := parse_float_args(args, k, x[:]); err != nil {
+			return vec.V3{}, err
+		}
+		return vec.V3(x), nil
+	}
+	return vec.V3{}, bad_field(-1, "wrong number of fields")
+}
+
+// parse an on/off option value for the k-th argument
+func parse_on_off(args []string, k int) (bool, error) {
+	switch args[k] {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, bad_field(k, "expected on/off")
+}
+
+// parse up to 3 floats for the -o, -s and -t options, return the number consumed
+func parse_uvw(args []string, v *vec.V3) int {
+	n := 0
+	for n < 3 && n < len(args) {
+		f, err := strconv.ParseFloat(args[n], 32)
+		if err != nil {
+			break
+		}
+		v[n] = float32(f)
+		n++
+	}
+	return n
+}
+
+// parse the options and filename of a texture map statement
+func parse_texture_map(args []string, dir string) (*Texture_Map, error) {
+	m := new_texture_map()
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		option := args[i]
+		need := func(n int) error {
+			if i+n >= len(args) {
+				return bad_field(i, "%s: not enough fields", option)
+			}
+			return nil
+		}
+		var err error
+		switch option {
+		case "-blendu", "-blendv", "-cc", "-clamp":
+			if err = need(1); err != nil {
+				return nil, err
+			}
+			var on bool
+			if on, err = parse_on_off(args, i+1); err != nil {
+				return nil, err
+			}
+			switch option {
+			case "-blendu":
+				m.Blend_U = on
+			case "-blendv":
+				m.Blend_V = on
+			case "-cc":
+				m.Color_Corr = on
+			case "-clamp":
+				m.Clamp = on
+			}
+			i += 2
+		case "-bm", "-boost":
+			if err = need(1); err != nil {
+				return nil, err
+			}
+			var x float32
+			if x, err = parse_float_arg(args, i+1); err != nil {
+				return nil, err
+			}
+			if option == "-bm" {
+				m.Bump_Mult = x
+			} else {
+				m.Boost = x
+			}
+			i += 2
+		case "-mm":
+			if err = need(2); err != nil {
+				return nil, err
+			}
+			var x [2]float32
+			if err = parse_float_args(args, i+1, x[:]); err != nil {
+				return nil, err
+			}
+			m.Base = x[0]
+			m.Gain = x[1]
+			i += 3
+		case "-o", "-s", "-t":
+			v := &m.Offset
+			if option == "-s" {
+				v = &m.Scale
+			} else if option == "-t" {
+				v = &m.Turbulence
+			}
+			n := parse_uvw(args[i+1:], v)
+			if n == 0 {
+				return nil, bad_field(i, "%s: cannot parse float", option)
+			}
+			i += 1 + n
+		case "-texres":
+			if err = need(1); err != nil {
+				return nil, err
+			}
+			if m.Resolution, err = strconv.Atoi(args[i+1]); err != nil {
+				return nil, bad_field(i+1, "cannot parse int")
+			}
+			i += 2
+		case "-imfchan":
+			if err = need(1); err != nil {
+				return nil, err
+			}
+			if len(args[i+1]) != 1 || !strings.Contains("rgbmlz", args[i+1]) {
+				return nil, bad_field(i+1, "bad channel")
+			}
+			m.Channel = args[i+1]
+			i += 2
+		case "-type":
+			if err = need(1); err != nil {
+				return nil, err
+			}
+			m.Reflection = args[i+1]
+			i += 2
+		default:
+			return nil, bad_field(i, "%s: unrecognized option", option)
+		}
+	}
+	if i >= len(args) {
+		return nil, bad_field(-1, "missing filename")
+	}
+	// the filename may contain spaces
+	m.Filename = strings.Join(args[i:], " ")
+	m.Path = m.Filename
+	if !filepath.IsAbs(m.Path) {
+		m.Path = filepath.Join(dir, m.Path)
+	}
+	return m, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// mtl file parser state
+type mtl_parser struct {
+	scanner
+	opts      *Options
+	dir       string
+	materials []*Material
+	m         *Material // current material
+}
+
+func (p *mtl_parser) statement(keyword string, args []string) error {
+
+	if keyword == "newmtl" {
+		if len(args) < 1 {
+			return bad_field(-1, "not enough fields")
+		}
+		p.m = new_material(strings.Join(args, " "))
+		p.materials = append(p.materials, p.m)
+		return nil
+	}
+
+	m := p.m
+	if m == nil {
+		return bad_field(-1, "no current material")
+	}
+
+	switch keyword {
+
+	case "Ka", "Kd", "Ks", "Ke", "Tf":
+		c, err := parse_color(args)
+		if err != nil {
+			return err
+		}
+		switch keyword {
+		case "Ka":
+			m.Ka = c
+		case "Kd":
+			m.Kd = c
+		case "Ks":
+			m.Ks = c
+		case "Ke":
+			m.Ke = c
+		case "Tf":
+			m.Tf = c
+		}
+
+	case "Ns", "Ni", "sharpness", "Tr":
+		if len(args) != 1 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		x, err := parse_float_arg(args, 0)
+		if err != nil {
+			return err
+		}
+		switch keyword {
+		case "Ns":
+			m.Ns = x
+		case "Ni":
+			m.Ni = x
+		case "sharpness":
+			m.Sharpness = x
+		case "Tr":
+			// transparency is the inverse of dissolve
+			m.D = 1 - x
+		}
+
+	case "d":
+		k := 0
+		m.Halo = len(args) >= 1 && args[0] == "-halo"
+		if m.Halo {
+			k = 1
+		}
+		if len(args) != k+1 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		x, err := parse_float_arg(args, k)
+		if err != nil {
+			return err
+		}
+		m.D = x
+
+	case "illum":
+		if len(args) != 1 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		x, err := strconv.Atoi(args[0])
+		if err != nil {
+			return bad_field(0, "cannot parse int")
+		}
+		m.Illum = x
+
+	case "map_Ka", "map_Kd", "map_Ks", "map_Ns", "map_d", "map_Bump", "map_bump", "bump", "disp", "decal", "refl":
+		t, err := parse_texture_map(args, p.dir)
+		if err != nil {
+			return err
+		}
+		switch keyword {
+		case "map_Ka":
+			m.Map_Ka = t
+		case "map_Kd":
+			m.Map_Kd = t
+		case "map_Ks":
+			m.Map_Ks = t
+		case "map_Ns":
+			m.Map_Ns = t
+		case "map_d":
+			m.Map_D = t
+		case "map_Bump", "map_bump", "bump":
+			m.Map_Bump = t
+		case "disp":
+			m.Disp = t
+		case "decal":
+			m.Decal = t
+		case "refl":
+			m.Refl = append(m.Refl, t)
+		}
+
+	default:
+		return p.unrecognized(p.opts.Lenient)
+	}
+
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// Parse_MTL reads materials from r. Texture map paths are relative to dir.
+// Options may be nil, only the lenient mode options apply.
+func Parse_MTL(r io.Reader, dir string, opts *Options) ([]*Material, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	p := &mtl_parser{
+		scanner: scanner{warn: opts.Warn},
+		opts:    opts,
+		dir:     dir,
+	}
+	if err := p.scan(r, p.statement); err != nil {
+		return nil, err
+	}
+	return p.materials, nil
+}
+
+// Read_MTL reads the materials from a material library file. Options may be nil.
+func Read_MTL(filename string, opts *Options) ([]*Material, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	p := &mtl_parser{
+		scanner: scanner{file: filename, warn: opts.Warn},
+		opts:    opts,
+		dir:     filepath.Dir(filename),
+	}
+	if err := p.scan(file, p.statement); err != nil {
+		return nil, err
+	}
+	return p.materials, nil
+}
+
+//-----------------------------------------------------------------------------
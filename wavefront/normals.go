@@ -0,0 +1,139 @@
+//-----------------------------------------------------------------------------
+/*
+
+Vertex Normal Generation
+
+Vertex normals are the weighted sum of the normals of the faces that share
+the vertex. Faces are only smoothed together when they are in the same
+(non-zero) smoothing group and the angle between their normals is within
+the crease angle. Faces with smoothing off get flat normals.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Weighting selects how face normals contribute to a vertex normal
+type Weighting int
+
+const (
+	Weight_Area  Weighting = iota // weighted by face area
+	Weight_Angle                  // weighted by the face angle at the vertex
+)
+
+// Normal_Options control vertex normal generation
+type Normal_Options struct {
+	Weighting Weighting
+	Crease    float32 // crease angle in degrees (0 disables the crease test)
+}
+
+//-----------------------------------------------------------------------------
+
+// return the angle at the j-th corner of a polygon
+func corner_angle(p []vec.V3, j int) float32 {
+	k := len(p)
+	a := p[(j+k-1)%k].Sub(p[j]).Normalize()
+	b := p[(j+1)%k].Sub(p[j]).Normalize()
+	d := float64(a.Dot(b))
+	return float32(math.Acos(math.Max(-1, math.Min(1, d))))
+}
+
+// a face corner
+type corner struct {
+	face, elem int
+}
+
+// Gen_Normals generates vertex normals for all faces.
+// Any existing vertex normals are replaced. Options may be nil.
+func (o *Object) Gen_Normals(opts *Normal_Options) {
+
+	if opts == nil {
+		opts = &Normal_Options{}
+	}
+
+	crease := float32(-2)
+	if opts.Crease > 0 {
+		crease = float32(math.Cos(float64(opts.Crease) * math.Pi / 180))
+	}
+
+	// face normals and per-corner weighted normals
+	unit := make([]vec.V3, len(o.f_list))
+	weighted := make([][]vec.V3, len(o.f_list))
+	incident := make(map[int][]corner)
+
+	for i := range o.f_list {
+		elem := o.face_elem(i)
+		p := make([]vec.V3, len(elem))
+		for j := range elem {
+			p[j] = o.vertex(elem[j].v)
+			incident[elem[j].v] = append(incident[elem[j].v], corner{i, j})
+		}
+		// the length of the newell normal is twice the polygon area
+		n := newell(p)
+		unit[i] = n.Normalize()
+		weighted[i] = make([]vec.V3, len(p))
+		for j := range p {
+			if opts.Weighting == Weight_Angle {
+				weighted[i][j] = unit[i].Scale(corner_angle(p, j))
+			} else {
+				weighted[i][j] = n
+			}
+		}
+	}
+
+	// build the new normal list, sharing identical normals per vertex
+	type key struct {
+		v int
+		n vec.V3
+	}
+	index := make(map[key]int)
+	var vn_list []float32
+
+	// the face elements may be shared with other objects, so make a new list
+	f_elem := make([]F_elem, 0, len(o.f_elem))
+	f_list := make([]Face, len(o.f_list))
+	for i, f := range o.f_list {
+		elem := o.face_elem(i)
+		f.elem = len(f_elem)
+		f_elem = append(f_elem, elem...)
+		for j := range elem {
+			n := unit[i]
+			if f.smooth != 0 {
+				var sum vec.V3
+				for _, c := range incident[elem[j].v] {
+					if o.f_list[c.face].smooth != f.smooth {
+						continue
+					}
+					if unit[i].Dot(unit[c.face]) < crease {
+						continue
+					}
+					sum = sum.Add(weighted[c.face][c.elem])
+				}
+				if sum.Length() != 0 {
+					n = sum.Normalize()
+				}
+			}
+			k := key{elem[j].v, n}
+			if _, ok := index[k]; !ok {
+				vn_list = append(vn_list, n[0], n[1], n[2])
+				index[k] = len(vn_list) / vn_stride
+			}
+			f_elem[f.elem+j].vn = index[k]
+		}
+		f_list[i] = f
+	}
+
+	o.vn_list = vn_list
+	o.f_elem = f_elem
+	o.f_list = f_list
+}
+
+//-----------------------------------------------------------------------------
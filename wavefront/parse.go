@@ -0,0 +1,764 @@
+//-----------------------------------------------------------------------------
+/*
+
+Parse Wavefront OBJ Files
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Options control how an object is read
+type Options struct {
+	Triangulate bool                  // split polygons into triangles
+	Objects     []string              // only keep faces from these named objects
+	Groups      []string              // only keep faces from these named groups
+	Dir         string                // directory for material libraries (Read uses the obj file directory)
+	Lenient     bool                  // skip unrecognized statements instead of failing
+	Warn        func(err *ParseError) // called for skipped statements in lenient mode (may be nil)
+	Workers     int                   // number of parsing goroutines for Load (0 for GOMAXPROCS)
+}
+
+// ParseError is an error at a specific location in an obj or mtl file
+type ParseError struct {
+	File    string // filename ("" if unknown)
+	Line    int    // line number (1-based)
+	Column  int    // column number (1-based)
+	Keyword string // statement keyword
+	Err     error  // underlying cause
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("%d:%d: %s: %v", e.Line, e.Column, e.Keyword, e.Err)
+	if e.Keyword == "" {
+		s = fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+	}
+	if e.File != "" {
+		return e.File + ":" + s
+	}
+	return "line " + s
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//-----------------------------------------------------------------------------
+
+// an error for the k-th argument of a statement (k < 0 for the keyword)
+type field_error struct {
+	k   int
+	err error
+}
+
+func (e *field_error) Error() string {
+	return e.err.Error()
+}
+
+// return an error for the k-th argument of a statement
+func bad_field(k int, format string, args ...interface{}) error {
+	return &field_error{k, fmt.Errorf(format, args...)}
+}
+
+// split a line into whitespace separated fields, return the fields and their 1-based columns.
+// The results are appended to the passed slices.
+func split_fields(line string, fields []string, cols []int) ([]string, []int) {
+	i := 0
+	for i < len(line) {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\r') {
+			i++
+		}
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' {
+			i++
+		}
+		if i > start {
+			fields = append(fields, line[start:i])
+			cols = append(cols, start+1)
+		}
+	}
+	return fields, cols
+}
+
+// strip a trailing comment from a line
+func strip_comment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// a line based statement scanner shared by the obj and mtl parsers
+type scanner struct {
+	file    string
+	line    int
+	keyword string
+	fields  []string
+	cols    []int
+	warn    func(err *ParseError)
+}
+
+// return a parse error for the current statement
+func (s *scanner) error(err error) *ParseError {
+	col := 1
+	if len(s.cols) != 0 {
+		col = s.cols[0]
+	}
+	var fe *field_error
+	if errors.As(err, &fe) {
+		if fe.k >= 0 && fe.k+1 < len(s.cols) {
+			col = s.cols[fe.k+1]
+		}
+		err = fe.err
+	}
+	return &ParseError{
+		File:    s.file,
+		Line:    s.line,
+		Column:  col,
+		Keyword: s.keyword,
+		Err:     err,
+	}
+}
+
+// skip an unrecognized statement, returns an error in strict mode
+func (s *scanner) unrecognized(lenient bool) error {
+	err := errors.New("unrecognized statement")
+	if !lenient {
+		return err
+	}
+	if s.warn != nil {
+		s.warn(s.error(err))
+	}
+	return nil
+}
+
+// scan the statements in r, calling statement for each of them
+func (s *scanner) scan(r io.Reader, statement func(keyword string, args []string) error) error {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		s.line++
+		s.fields, s.cols = split_fields(strip_comment(lines.Text()), s.fields[:0], s.cols[:0])
+		if len(s.fields) == 0 {
+			continue
+		}
+		s.keyword = s.fields[0]
+		if err := statement(s.fields[0], s.fields[1:]); err != nil {
+			return s.error(err)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return &ParseError{File: s.file, Line: s.line + 1, Column: 1, Err: err}
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// parse n float arguments (starting at argument k) into x
+func parse_float_args(args []string, k int, x []float32) error {
+	for i := range x {
+		f, err := strconv.ParseFloat(args[k+i], 32)
+		if err != nil {
+			return bad_field(k+i, "cannot parse float")
+		}
+		x[i] = float32(f)
+	}
+	return nil
+}
+
+// split a face element "v/vt/vn" into its indices, return the number of indices
+func split_indices(s string) ([3]string, int) {
+	var x [3]string
+	n := 0
+	for {
+		i := strings.IndexByte(s, '/')
+		if i < 0 || n == 2 {
+			if n < 3 {
+				x[n] = s
+			}
+			if i >= 0 {
+				// too many indices
+				return x, 4
+			}
+			return x, n + 1
+		}
+		x[n] = s[:i]
+		s = s[i+1:]
+		n++
+	}
+}
+
+// resolve_index converts a face index to an absolute index in a list of length n.
+// Negative indices are relative to the end of the list, zero is invalid.
+func resolve_index(s string, n int) (int, error) {
+	x, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("index is not an integer")
+	}
+	if x == 0 {
+		return 0, fmt.Errorf("index is zero")
+	}
+	if x < 0 {
+		x += n + 1
+		if x < 1 {
+			return 0, fmt.Errorf("relative index out of range")
+		}
+	}
+	if x > n {
+		return 0, fmt.Errorf("index out of range")
+	}
+	return x, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// statements that set the state for the following faces
+const (
+	state_o = iota // object name
+	state_g        // group names
+	state_s        // smoothing group
+	state_m        // material
+	n_states
+)
+
+// counts of faces, lines and points
+type elem_count struct {
+	f, l, p int
+}
+
+// obj file parser state.
+// A parser handles a chunk of the input, the chunks are merged afterwards.
+type parser struct {
+	scanner
+	opts      *Options
+	obj       *Object // vertices, faces and names for this chunk
+	base_v    int     // geometric vertices in preceding chunks
+	base_vt   int     // texture vertices in preceding chunks
+	base_vn   int     // vertex normals in preceding chunks
+	material  int     // current material (index to usemtl names)
+	object    int     // current object name
+	groups    []int   // current group names
+	group_set int     // current group set (-1 if not yet added)
+	smooth    int     // current smoothing group
+	sets      map[string]int
+	mtl_names []string             // names from usemtl statements
+	mtl_pos   [][2]int             // line and column of usemtl statements
+	lead      [n_states]elem_count // elements before the first state statement (-1 if not seen)
+	warnings  []*ParseError
+	err       error
+}
+
+// return a new parser for a chunk starting after the given line and vertex counts
+func new_parser(opts *Options, file string, line, nv, nvt, nvn int) *parser {
+	p := &parser{
+		opts:    opts,
+		obj:     &Object{},
+		base_v:  nv,
+		base_vt: nvt,
+		base_vn: nvn,
+		sets:    make(map[string]int),
+	}
+	p.file = file
+	p.line = line
+	p.warn = func(err *ParseError) {
+		p.warnings = append(p.warnings, err)
+	}
+	// material libraries report their warnings through the parser
+	mtl_opts := *opts
+	mtl_opts.Warn = p.warn
+	p.opts = &mtl_opts
+	for i := range p.lead {
+		p.lead[i] = elem_count{-1, -1, -1}
+	}
+	return p
+}
+
+// note the first statement of each state type
+func (p *parser) set_state(k int) {
+	if p.lead[k].f < 0 {
+		p.lead[k] = p.count()
+	}
+}
+
+// return the number of elements in the chunk
+func (p *parser) count() elem_count {
+	return elem_count{len(p.obj.f_list), len(p.obj.l_list), len(p.obj.p_list)}
+}
+
+// return the current state for a new element
+func (p *parser) element(elem, n int) Element {
+	return Element{
+		elem:     elem,
+		n:        n,
+		material: p.material,
+		object:   p.object,
+		groups:   p.current_group_set(),
+	}
+}
+
+// return the key for a set of indices
+func set_key(set []int) string {
+	return fmt.Sprint(set)
+}
+
+// return the current group set, adding it if needed
+func (p *parser) current_group_set() int {
+	if p.group_set < 0 {
+		key := set_key(p.groups)
+		k, ok := p.sets[key]
+		if !ok {
+			k = p.obj.Add_Group_Set(p.groups)
+			p.sets[key] = k
+		}
+		p.group_set = k
+	}
+	return p.group_set
+}
+
+// parse the chunk statements in r
+func (p *parser) parse(r io.Reader) {
+	p.err = p.scan(r, p.statement)
+	p.current_group_set()
+}
+
+func (p *parser) statement(keyword string, args []string) error {
+
+	n := len(args)
+
+	switch keyword {
+
+	case "o":
+		// object name
+		if n < 1 {
+			return bad_field(-1, "not enough fields")
+		}
+		p.set_state(state_o)
+		p.object = p.obj.Add_Object_Name(strings.Join(args, " "))
+
+	case "g":
+		// group names
+		p.set_state(state_g)
+		p.groups = nil
+		for _, name := range args {
+			p.groups = append(p.groups, p.obj.Add_Group_Name(name))
+		}
+		p.group_set = -1
+
+	case "s":
+		// smoothing group
+		if n != 1 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		smooth := 0
+		if args[0] != "off" {
+			x, err := strconv.Atoi(args[0])
+			if err != nil || x < 0 {
+				return bad_field(0, "bad smoothing group")
+			}
+			smooth = x
+		}
+		p.set_state(state_s)
+		p.smooth = smooth
+
+	case "mtllib":
+		// material library (paths are relative to the object file)
+		if n < 1 {
+			return bad_field(-1, "not enough fields")
+		}
+		for k, name := range args {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(p.opts.Dir, name)
+			}
+			materials, err := Read_MTL(name, p.opts)
+			if err != nil {
+				return &field_error{k, err}
+			}
+			for _, m := range materials {
+				p.obj.Add_Material(m)
+			}
+		}
+
+	case "usemtl":
+		// use material (the name is resolved once all libraries are read)
+		if n != 1 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		p.set_state(state_m)
+		p.mtl_names = append(p.mtl_names, args[0])
+		p.mtl_pos = append(p.mtl_pos, [2]int{p.line, p.cols[1]})
+		p.material = len(p.mtl_names)
+
+	case "l":
+		// line
+		if n < 2 {
+			return bad_field(-1, "not enough fields")
+		}
+		nv := p.base_v + p.obj.Len_V()
+		nvt := p.base_vt + p.obj.Len_VT()
+		l := p.element(len(p.obj.l_elem), n)
+		for i := 0; i < n; i++ {
+			var e F_elem
+			indices, k := split_indices(args[i])
+			if k > 2 {
+				return bad_field(i, "bad line indices")
+			}
+			// index to geometric vertex
+			x, err := resolve_index(indices[0], nv)
+			if err != nil {
+				return bad_field(i, "v %s", err)
+			}
+			e.v = x
+			// index to texture vertex
+			if k == 2 {
+				x, err := resolve_index(indices[1], nvt)
+				if err != nil {
+					return bad_field(i, "vt %s", err)
+				}
+				e.vt = x
+			}
+			p.obj.l_elem = append(p.obj.l_elem, e)
+		}
+		p.obj.l_list = append(p.obj.l_list, l)
+
+	case "p":
+		// points
+		if n < 1 {
+			return bad_field(-1, "not enough fields")
+		}
+		nv := p.base_v + p.obj.Len_V()
+		pt := p.element(len(p.obj.p_elem), n)
+		for i := 0; i < n; i++ {
+			x, err := resolve_index(args[i], nv)
+			if err != nil {
+				return bad_field(i, "v %s", err)
+			}
+			p.obj.p_elem = append(p.obj.p_elem, F_elem{v: x})
+		}
+		p.obj.p_list = append(p.obj.p_list, pt)
+
+	case "v":
+		// geometric vertex
+		if n < 3 {
+			return bad_field(-1, "not enough fields")
+		}
+		if n > 4 {
+			return bad_field(4, "too many fields")
+		}
+		x := [4]float32{0, 0, 0, 1}
+		if err := parse_float_args(args, 0, x[:n]); err != nil {
+			return err
+		}
+		p.obj.v_list = append(p.obj.v_list, x[:]...)
+
+	case "vt":
+		// texture vertex
+		if n < 2 {
+			return bad_field(-1, "not enough fields")
+		}
+		if n > 3 {
+			return bad_field(3, "too many fields")
+		}
+		var x [3]float32
+		if err := parse_float_args(args, 0, x[:n]); err != nil {
+			return err
+		}
+		p.obj.vt_list = append(p.obj.vt_list, x[:]...)
+
+	case "vn":
+		// vertex normal
+		if n != 3 {
+			return bad_field(-1, "wrong number of fields")
+		}
+		var x [3]float32
+		if err := parse_float_args(args, 0, x[:]); err != nil {
+			return err
+		}
+		p.obj.vn_list = append(p.obj.vn_list, x[:]...)
+
+	case "f":
+		// face
+		if n < 3 {
+			return bad_field(-1, "not enough fields")
+		}
+		nv := p.base_v + p.obj.Len_V()
+		nvt := p.base_vt + p.obj.Len_VT()
+		nvn := p.base_vn + p.obj.Len_VN()
+		f := Face{p.element(len(p.obj.f_elem), n), p.smooth}
+		for i := 0; i < n; i++ {
+			var e F_elem
+			indices, k := split_indices(args[i])
+			if k > 3 {
+				return bad_field(i, "bad face indices")
+			}
+			// index to geometric vertex
+			x, err := resolve_index(indices[0], nv)
+			if err != nil {
+				return bad_field(i, "v %s", err)
+			}
+			e.v = x
+			// index to texture vertex (could be empty)
+			if k >= 2 && len(indices[1]) > 0 {
+				x, err := resolve_index(indices[1], nvt)
+				if err != nil {
+					return bad_field(i, "vt %s", err)
+				}
+				e.vt = x
+			}
+			// index to vertex normal
+			if k >= 3 {
+				x, err := resolve_index(indices[2], nvn)
+				if err != nil {
+					return bad_field(i, "vn %s", err)
+				}
+				e.vn = x
+			}
+			p.obj.f_elem = append(p.obj.f_elem, e)
+		}
+		p.obj.f_list = append(p.obj.f_list, f)
+
+	default:
+		return p.unrecognized(p.opts.Lenient)
+	}
+
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// merge the parsed chunks into a single object.
+// The result is the same as parsing the chunks in sequence.
+func merge(chunks []*parser, opts *Options) (*Object, error) {
+
+	// stop at the first chunk with an error
+	for i, c := range chunks {
+		if c.err != nil {
+			chunks = chunks[:i+1]
+			break
+		}
+	}
+
+	obj := &Object{}
+	for _, c := range chunks {
+		obj.mtl_list = append(obj.mtl_list, c.obj.mtl_list...)
+	}
+
+	// resolve the usemtl names
+	remap_m := make([][]int, len(chunks))
+	for i, c := range chunks {
+		if opts.Warn != nil {
+			for _, w := range c.warnings {
+				opts.Warn(w)
+			}
+		}
+		remap_m[i] = make([]int, len(c.mtl_names)+1)
+		for k, name := range c.mtl_names {
+			m := obj.Find_Material(name)
+			if m == 0 {
+				return nil, &ParseError{
+					File:    c.file,
+					Line:    c.mtl_pos[k][0],
+					Column:  c.mtl_pos[k][1],
+					Keyword: "usemtl",
+					Err:     errors.New("material not found"),
+				}
+			}
+			remap_m[i][k+1] = m
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+	}
+
+	if len(chunks) == 1 {
+		// no carried state, use the chunk lists as they are
+		c := chunks[0]
+		obj.v_list = c.obj.v_list
+		obj.vt_list = c.obj.vt_list
+		obj.vn_list = c.obj.vn_list
+		obj.f_elem = c.obj.f_elem
+		obj.l_elem = c.obj.l_elem
+		obj.p_elem = c.obj.p_elem
+	}
+
+	sets := make(map[string]int)
+	var state [n_states]int // state carried from the previous chunk
+
+	for i, c := range chunks {
+
+		// remap the chunk name indices to the merged indices
+		remap_o := make([]int, len(c.obj.o_names)+1)
+		for k, name := range c.obj.o_names {
+			remap_o[k+1] = obj.Add_Object_Name(name)
+		}
+		remap_g := make([]int, len(c.obj.g_names)+1)
+		for k, name := range c.obj.g_names {
+			remap_g[k+1] = obj.Add_Group_Name(name)
+		}
+		remap_s := make([]int, len(c.obj.g_sets)+1)
+		for k, set := range c.obj.g_sets {
+			x := make([]int, len(set))
+			for j := range set {
+				x[j] = remap_g[set[j]]
+			}
+			key := set_key(x)
+			if _, ok := sets[key]; !ok {
+				sets[key] = obj.Add_Group_Set(x)
+			}
+			remap_s[k+1] = sets[key]
+		}
+
+		// elements before the first state statement inherit the carried state
+		lead := c.lead
+		for k := range lead {
+			if lead[k].f < 0 {
+				lead[k] = c.count()
+			}
+		}
+
+		// remap an element, k is its index and lead returns the lead count for a state
+		remap := func(e *Element, k int, lead func(state int) int) {
+			e.object = remap_o[e.object]
+			e.groups = remap_s[e.groups]
+			e.material = remap_m[i][e.material]
+			if k < lead(state_o) {
+				e.object = state[state_o]
+			}
+			if k < lead(state_g) {
+				e.groups = state[state_g]
+			}
+			if k < lead(state_m) {
+				e.material = state[state_m]
+			}
+		}
+
+		f_ofs, l_ofs, p_ofs := 0, 0, 0
+		if len(chunks) != 1 {
+			f_ofs = len(obj.f_elem)
+			l_ofs = len(obj.l_elem)
+			p_ofs = len(obj.p_elem)
+			obj.v_list = append(obj.v_list, c.obj.v_list...)
+			obj.vt_list = append(obj.vt_list, c.obj.vt_list...)
+			obj.vn_list = append(obj.vn_list, c.obj.vn_list...)
+			obj.f_elem = append(obj.f_elem, c.obj.f_elem...)
+			obj.l_elem = append(obj.l_elem, c.obj.l_elem...)
+			obj.p_elem = append(obj.p_elem, c.obj.p_elem...)
+		}
+
+		for k, f := range c.obj.f_list {
+			f.elem += f_ofs
+			remap(&f.Element, k, func(s int) int { return lead[s].f })
+			if k < lead[state_s].f {
+				f.smooth = state[state_s]
+			}
+			obj.f_list = append(obj.f_list, f)
+		}
+		for k, l := range c.obj.l_list {
+			l.elem += l_ofs
+			remap(&l, k, func(s int) int { return lead[s].l })
+			obj.l_list = append(obj.l_list, l)
+		}
+		for k, pt := range c.obj.p_list {
+			pt.elem += p_ofs
+			remap(&pt, k, func(s int) int { return lead[s].p })
+			obj.p_list = append(obj.p_list, pt)
+		}
+
+		// carry the final chunk state
+		if c.lead[state_o].f >= 0 {
+			state[state_o] = remap_o[c.object]
+		}
+		if c.lead[state_g].f >= 0 {
+			state[state_g] = remap_s[c.group_set]
+		}
+		if c.lead[state_s].f >= 0 {
+			state[state_s] = c.smooth
+		}
+		if c.lead[state_m].f >= 0 {
+			state[state_m] = remap_m[i][c.material]
+		}
+	}
+
+	return obj, nil
+}
+
+// apply the face selection and triangulation options
+func (o *Object) apply_options(opts *Options) *Object {
+	if len(opts.Objects) != 0 {
+		o = o.Select_Objects(opts.Objects...)
+	}
+	if len(opts.Groups) != 0 {
+		o = o.Select_Groups(opts.Groups...)
+	}
+	if opts.Triangulate {
+		o.Triangulate()
+	}
+	return o
+}
+
+//-----------------------------------------------------------------------------
+
+// Parse reads a wavefront object from r. Options may be nil.
+// Errors in the object data are returned as a *ParseError.
+func Parse(r io.Reader, opts *Options) (*Object, error) {
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	p := new_parser(opts, "", 0, 0, 0, 0)
+	p.parse(r)
+
+	obj, err := merge([]*parser{p}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return obj.apply_options(opts), nil
+}
+
+// return the options for reading a file
+func file_options(filename string, opts *Options) *Options {
+	var file_opts Options
+	if opts != nil {
+		file_opts = *opts
+	}
+	if file_opts.Dir == "" {
+		file_opts.Dir = filepath.Dir(filename)
+	}
+	return &file_opts
+}
+
+// Read a wavefront object file. Options may be nil.
+func Read(filename string, opts *Options) (*Object, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	file_opts := file_options(filename, opts)
+	p := new_parser(file_opts, filename, 0, 0, 0, 0)
+	p.parse(file)
+
+	obj, err := merge([]*parser{p}, file_opts)
+	if err != nil {
+		return nil, err
+	}
+	return obj.apply_options(file_opts), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,227 @@
+//-----------------------------------------------------------------------------
+/*
+
+Polygon Triangulation
+
+Convex planar polygons are split into a triangle fan.
+Concave or non-planar polygons are projected onto the plane given by
+their Newell normal and split using ear clipping.
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"math"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// relative tolerance for planarity tests
+const planar_epsilon = 1e-4
+
+// return the polygon normal using Newell's method (not normalized)
+func newell(p []vec.V3) vec.V3 {
+	var n vec.V3
+	for i := range p {
+		a := p[i]
+		b := p[(i+1)%len(p)]
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	return n
+}
+
+// return the length of the bounding box diagonal for the points
+func extent(p []vec.V3) float32 {
+	min := p[0]
+	max := p[0]
+	for _, x := range p[1:] {
+		for j := 0; j < 3; j++ {
+			if x[j] < min[j] {
+				min[j] = x[j]
+			}
+			if x[j] > max[j] {
+				max[j] = x[j]
+			}
+		}
+	}
+	return max.Sub(min).Length()
+}
+
+// return true if all points lie on the plane with unit normal n
+func planar(p []vec.V3, n vec.V3) bool {
+	d := n.Dot(p[0])
+	tolerance := planar_epsilon * extent(p)
+	for _, x := range p[1:] {
+		if float32(math.Abs(float64(n.Dot(x)-d))) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// return true if every corner turns the same way around the normal
+func convex(p []vec.V3, n vec.V3) bool {
+	k := len(p)
+	for i := 0; i < k; i++ {
+		a := p[(i+k-1)%k]
+		b := p[i]
+		c := p[(i+1)%k]
+		if b.Sub(a).Cross(c.Sub(b)).Dot(n) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// fan triangulation around the first vertex
+func fan(k int) [][3]int {
+	t := make([][3]int, 0, k-2)
+	for i := 1; i < k-1; i++ {
+		t = append(t, [3]int{0, i, i + 1})
+	}
+	return t
+}
+
+//-----------------------------------------------------------------------------
+// ear clipping
+
+// return the z component of (b - a) x (c - a)
+func cross2(a, b, c vec.V2) float32 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// return true if p is inside (or on the boundary of) the ccw triangle abc
+func in_triangle(a, b, c, p vec.V2) bool {
+	return cross2(a, b, p) >= 0 && cross2(b, c, p) >= 0 && cross2(c, a, p) >= 0
+}
+
+// project the points onto the plane with normal n, keeping ccw order
+func project(p []vec.V3, n vec.V3) []vec.V2 {
+	// drop the dominant axis of the normal
+	ax := [3]float32{
+		float32(math.Abs(float64(n[0]))),
+		float32(math.Abs(float64(n[1]))),
+		float32(math.Abs(float64(n[2]))),
+	}
+	i, j, k := 0, 1, 2
+	if ax[0] >= ax[1] && ax[0] >= ax[2] {
+		i, j, k = 1, 2, 0
+	} else if ax[1] >= ax[2] {
+		i, j, k = 2, 0, 1
+	}
+	// flip to keep the winding counter clockwise
+	if n[k] < 0 {
+		i, j = j, i
+	}
+	q := make([]vec.V2, len(p))
+	for m := range p {
+		q[m] = vec.V2{p[m][i], p[m][j]}
+	}
+	return q
+}
+
+// ear clipping triangulation of a ccw simple polygon
+func ear_clip(p []vec.V2) [][3]int {
+	idx := make([]int, len(p))
+	for i := range idx {
+		idx[i] = i
+	}
+	t := make([][3]int, 0, len(p)-2)
+
+	for len(idx) > 3 {
+		k := len(idx)
+		found := false
+		for i := 0; i < k; i++ {
+			a := idx[(i+k-1)%k]
+			b := idx[i]
+			c := idx[(i+1)%k]
+			if cross2(p[a], p[b], p[c]) <= 0 {
+				// reflex or degenerate corner
+				continue
+			}
+			ear := true
+			for _, m := range idx {
+				if m == a || m == b || m == c {
+					continue
+				}
+				if in_triangle(p[a], p[b], p[c], p[m]) {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				t = append(t, [3]int{a, b, c})
+				idx = append(idx[:i], idx[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			// self intersecting or degenerate - fan the remainder
+			for i := 1; i < len(idx)-1; i++ {
+				t = append(t, [3]int{idx[0], idx[i], idx[i+1]})
+			}
+			return t
+		}
+	}
+
+	return append(t, [3]int{idx[0], idx[1], idx[2]})
+}
+
+//-----------------------------------------------------------------------------
+
+// triangulate a polygon, return the index triples of the triangles
+func triangulate(p []vec.V3) [][3]int {
+	k := len(p)
+	if k < 3 {
+		return nil
+	}
+	if k == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+	n := newell(p)
+	if n.Length() == 0 {
+		// degenerate polygon
+		return fan(k)
+	}
+	n = n.Normalize()
+	if planar(p, n) && convex(p, n) {
+		return fan(k)
+	}
+	return ear_clip(project(p, n))
+}
+
+// Triangulate replaces all polygonal faces with triangles
+func (o *Object) Triangulate() {
+	f_elem := make([]F_elem, 0, len(o.f_elem))
+	f_list := make([]Face, 0, len(o.f_list))
+	for i, f := range o.f_list {
+		elem := o.face_elem(i)
+		if f.n == 3 {
+			f.elem = len(f_elem)
+			f_elem = append(f_elem, elem...)
+			f_list = append(f_list, f)
+			continue
+		}
+		p := make([]vec.V3, f.n)
+		for j := range elem {
+			p[j] = o.vertex(elem[j].v)
+		}
+		for _, x := range triangulate(p) {
+			f.elem = len(f_elem)
+			f.n = 3
+			f_elem = append(f_elem, elem[x[0]], elem[x[1]], elem[x[2]])
+			f_list = append(f_list, f)
+		}
+	}
+	o.f_elem = f_elem
+	o.f_list = f_list
+}
+
+//-----------------------------------------------------------------------------
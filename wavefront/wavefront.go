@@ -3,10 +3,7 @@
 package wavefront
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/deadsy/sw_render/vec"
@@ -37,19 +34,50 @@ type F_elem struct {
 	vn int // index for vertex normal
 }
 
+// an element with a list of vertices: a face, a polyline or a set of points
+type Element struct {
+	elem     int // offset of the first vertex in the element list
+	n        int // number of vertices
+	material int // index to material (0 for none)
+	object   int // index to object name (0 for none)
+	groups   int // index to group set (0 for none)
+}
+
+// polygonal face with 3 or more vertices
+type Face struct {
+	Element
+	smooth int // smoothing group (0 for off)
+}
+
+// flat list strides
+const (
+	v_stride  = 4 // x, y, z, w
+	vt_stride = 3 // u, v, w
+	vn_stride = 3 // x, y, z
+)
+
 // Object contains a name and a list of groups
 type Object struct {
-	v_list  []*V_elem
-	vt_list []*VT_elem
-	vn_list []*VN_elem
-	f_list  []*[3]F_elem
+	v_list   []float32 // geometric vertices
+	vt_list  []float32 // texture vertices
+	vn_list  []float32 // vertex normals
+	f_elem   []F_elem  // face elements
+	f_list   []Face
+	l_elem   []F_elem  // line elements (v and vt)
+	l_list   []Element // polylines
+	p_elem   []F_elem  // point elements (v only)
+	p_list   []Element // point sets
+	mtl_list []*Material
+	o_names  []string
+	g_names  []string
+	g_sets   [][]int // sets of group names used by faces
 }
 
 //-----------------------------------------------------------------------------
 // operations on geometric vertices
 
 // offset and scale
-func (v *V_elem) Scale(ofs, scale *[3]float32) *[3]int {
+func (v V_elem) Scale(ofs, scale *[3]float32) *[3]int {
 	return &[3]int{
 		int((v.x[0] + ofs[0]) * scale[0]),
 		int((v.x[1] + ofs[1]) * scale[1]),
@@ -58,56 +86,149 @@ func (v *V_elem) Scale(ofs, scale *[3]float32) *[3]int {
 }
 
 // convert a vertex to a V3
-func (v *V_elem) ToV3() vec.V3 {
+func (v V_elem) ToV3() vec.V3 {
 	return vec.V3{v.x[0], v.x[1], v.x[2]}
 }
 
+// convert a texture vertex to a V2
+func (vt VT_elem) ToV2() vec.V2 {
+	return vec.V2{vt.x[0], vt.x[1]}
+}
+
+// convert a vertex normal to a V3
+func (vn VN_elem) ToV3() vec.V3 {
+	return vec.V3{vn.x[0], vn.x[1], vn.x[2]}
+}
+
 //-----------------------------------------------------------------------------
 // operations on objects
 
 func (o *Object) Add_V(v *V_elem) {
-	o.v_list = append(o.v_list, v)
+	o.v_list = append(o.v_list, v.x[0], v.x[1], v.x[2], v.w)
 }
 
 func (o *Object) Add_VT(vt *VT_elem) {
-	o.vt_list = append(o.vt_list, vt)
+	o.vt_list = append(o.vt_list, vt.x[0], vt.x[1], vt.w)
 }
 
 func (o *Object) Add_VN(vn *VN_elem) {
-	o.vn_list = append(o.vn_list, vn)
+	o.vn_list = append(o.vn_list, vn.x[0], vn.x[1], vn.x[2])
 }
 
-func (o *Object) Add_F(f *[3]F_elem) {
+// add a face with the given elements
+func (o *Object) Add_F(f Face, elem []F_elem) {
+	f.elem = len(o.f_elem)
+	f.n = len(elem)
+	o.f_elem = append(o.f_elem, elem...)
 	o.f_list = append(o.f_list, f)
 }
 
+// add a material, return its index
+func (o *Object) Add_Material(m *Material) int {
+	o.mtl_list = append(o.mtl_list, m)
+	return len(o.mtl_list)
+}
+
+// return the index of the named material (0 if not found)
+func (o *Object) Find_Material(name string) int {
+	// later definitions override earlier ones
+	for i := len(o.mtl_list) - 1; i >= 0; i-- {
+		if o.mtl_list[i].Name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
 func (o *Object) Len_V() int {
-	return len(o.v_list)
+	return len(o.v_list) / v_stride
 }
 
 func (o *Object) Len_VT() int {
-	return len(o.vt_list)
+	return len(o.vt_list) / vt_stride
 }
 
 func (o *Object) Len_VN() int {
-	return len(o.vn_list)
+	return len(o.vn_list) / vn_stride
 }
 
 func (o *Object) Len_F() int {
 	return len(o.f_list)
 }
 
+func (o *Object) Len_Material() int {
+	return len(o.mtl_list)
+}
+
+// return the i-th material
+func (o *Object) Material(i int) *Material {
+	return o.mtl_list[i]
+}
+
+// return the number of vertices in the i-th face
+func (o *Object) Len_FV(i int) int {
+	return o.f_list[i].n
+}
+
+// return the elements of the i-th face
+func (o *Object) face_elem(i int) []F_elem {
+	f := &o.f_list[i]
+	return o.f_elem[f.elem : f.elem+f.n]
+}
+
+// return the k-th (1-based) geometric vertex position
+func (o *Object) vertex(k int) vec.V3 {
+	x := o.v_list[(k-1)*v_stride:]
+	return vec.V3{x[0], x[1], x[2]}
+}
+
 // return the j-th vertex from the i-th face
-func (o *Object) Get_V(i, j int) *V_elem {
-	return o.v_list[o.f_list[i][j].v-1]
+func (o *Object) Get_V(i, j int) V_elem {
+	x := o.v_list[(o.face_elem(i)[j].v-1)*v_stride:]
+	return V_elem{x: [3]float32{x[0], x[1], x[2]}, w: x[3]}
+}
+
+// return the j-th texture vertex from the i-th face (nil for none)
+func (o *Object) Get_VT(i, j int) *VT_elem {
+	k := o.face_elem(i)[j].vt
+	if k == 0 {
+		return nil
+	}
+	x := o.vt_list[(k-1)*vt_stride:]
+	return &VT_elem{x: [2]float32{x[0], x[1]}, w: x[2]}
+}
+
+// return the j-th vertex normal from the i-th face (nil for none)
+func (o *Object) Get_VN(i, j int) *VN_elem {
+	k := o.face_elem(i)[j].vn
+	if k == 0 {
+		return nil
+	}
+	x := o.vn_list[(k-1)*vn_stride:]
+	return &VN_elem{x: [3]float32{x[0], x[1], x[2]}}
+}
+
+// return the smoothing group for the i-th face (0 for off)
+func (o *Object) Get_Smooth(i int) int {
+	return o.f_list[i].smooth
+}
+
+// return the material for the i-th face (nil for none)
+func (o *Object) Get_Material(i int) *Material {
+	return o.element_material(&o.f_list[i].Element)
 }
 
 func (o *Object) String() string {
 	var s []string
-	s = append(s, fmt.Sprintf("geometric vertices %d", len(o.v_list)))
-	s = append(s, fmt.Sprintf("texture vertices %d", len(o.vt_list)))
-	s = append(s, fmt.Sprintf("vertex normals %d", len(o.vn_list)))
+	s = append(s, fmt.Sprintf("geometric vertices %d", o.Len_V()))
+	s = append(s, fmt.Sprintf("texture vertices %d", o.Len_VT()))
+	s = append(s, fmt.Sprintf("vertex normals %d", o.Len_VN()))
 	s = append(s, fmt.Sprintf("faces %d", len(o.f_list)))
+	s = append(s, fmt.Sprintf("lines %d", len(o.l_list)))
+	s = append(s, fmt.Sprintf("points %d", len(o.p_list)))
+	s = append(s, fmt.Sprintf("materials %d", len(o.mtl_list)))
+	s = append(s, fmt.Sprintf("objects %d", len(o.o_names)))
+	s = append(s, fmt.Sprintf("groups %d", len(o.g_names)))
 	s = append(s, fmt.Sprintf("bounds-x %f %f", o.Min_V(0), o.Max_V(0)))
 	s = append(s, fmt.Sprintf("bounds-y %f %f", o.Min_V(1), o.Max_V(1)))
 	s = append(s, fmt.Sprintf("bounds-z %f %f", o.Min_V(2), o.Max_V(2)))
@@ -131,10 +252,10 @@ func (o *Object) Max_V(j int) float32 {
 	x := float32(0)
 	if len(o.v_list) != 0 {
 		// initial value
-		x = o.v_list[0].x[j]
-		for i := 0; i < len(o.v_list); i++ {
-			if o.v_list[i].x[j] > x {
-				x = o.v_list[i].x[j]
+		x = o.v_list[j]
+		for i := j; i < len(o.v_list); i += v_stride {
+			if o.v_list[i] > x {
+				x = o.v_list[i]
 			}
 		}
 	}
@@ -146,10 +267,10 @@ func (o *Object) Min_V(j int) float32 {
 	x := float32(0)
 	if len(o.v_list) != 0 {
 		// initial value
-		x = o.v_list[0].x[j]
-		for i := 0; i < len(o.v_list); i++ {
-			if o.v_list[i].x[j] < x {
-				x = o.v_list[i].x[j]
+		x = o.v_list[j]
+		for i := j; i < len(o.v_list); i += v_stride {
+			if o.v_list[i] < x {
+				x = o.v_list[i]
 			}
 		}
 	}
@@ -157,183 +278,3 @@ func (o *Object) Min_V(j int) float32 {
 }
 
 //-----------------------------------------------------------------------------
-
-func Read(filename string) (*Object, error) {
-
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	line_number := 0
-	line := ""
-	scanner := bufio.NewScanner(file)
-
-	fail := func(msg string) error {
-		return fmt.Errorf(msg+" at line %d", line_number)
-	}
-
-	var object Object
-
-	for scanner.Scan() {
-		line_number++
-		line = scanner.Text()
-
-		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, " ") {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		n_fields := len(fields)
-
-		if n_fields == 0 {
-			continue
-		}
-
-		n_fields -= 1
-
-		switch fields[0] {
-
-		case "o":
-			// object name
-			// TODO
-
-		case "g":
-			// group name
-			// TODO
-
-		case "s":
-			// smoothing object
-			// TODO
-
-		case "mtllib":
-			// material library
-			// TODO
-
-		case "usemtl":
-			// use material
-			// TODO
-
-		case "l":
-			// line
-			// TODO
-
-		case "v":
-			// geometric vertex
-			if n_fields < 3 {
-				return nil, fail("v: not enough fields")
-			}
-			if n_fields > 4 {
-				return nil, fail("v: too many fields")
-			}
-			var x [4]float32
-			x[3] = 1
-			for i := 0; i < n_fields; i++ {
-				f, err := strconv.ParseFloat(fields[i+1], 32)
-				if err != nil {
-					return nil, fail("cannot parse float")
-				}
-				x[i] = float32(f)
-			}
-			v := V_elem{w: x[3]}
-			copy(v.x[:], x[0:3])
-			object.Add_V(&v)
-
-		case "vt":
-			// texture vertex
-			if n_fields < 2 {
-				return nil, fail("vt: not enough fields")
-			}
-			if n_fields < 3 {
-				return nil, fail("vt: too many fields")
-			}
-			var x [3]float32
-			x[2] = 0
-			for i := 0; i < n_fields; i++ {
-				f, err := strconv.ParseFloat(fields[i+1], 32)
-				if err != nil {
-					return nil, fail("cannot parse float")
-				}
-				x[i] = float32(f)
-			}
-			vt := VT_elem{w: x[2]}
-			copy(vt.x[:], x[0:2])
-			object.Add_VT(&vt)
-
-		case "vn":
-			// vertex normal
-			if n_fields != 3 {
-				return nil, fail("vn: wrong number of fields")
-			}
-			var vn VN_elem
-			for i := 0; i < 3; i++ {
-				f, err := strconv.ParseFloat(fields[i+1], 32)
-				if err != nil {
-					return nil, fail("cannot parse float")
-				}
-				vn.x[i] = float32(f)
-			}
-			object.Add_VN(&vn)
-
-		case "f":
-			// face
-			if n_fields != 3 {
-				return nil, fail("f: wrong number of fields")
-			}
-			var f [3]F_elem
-			for i := 0; i < 3; i++ {
-				indices := strings.Split(fields[i+1], "/")
-				if len(indices) >= 1 {
-					// index to geometric vertex
-					x, err := strconv.Atoi(indices[0])
-					if err != nil {
-						return nil, fail("bad face geometric vertex index")
-					}
-					if x > object.Len_V() {
-						return nil, fail("v index out of range")
-					}
-					f[i].v = x
-					// index to texture vertex (could be empty)
-					if len(indices) >= 2 {
-						if len(indices[1]) > 0 {
-							x, err := strconv.Atoi(indices[1])
-							if err != nil {
-								return nil, fail("bad face texture vertex")
-							}
-							if x > object.Len_VT() {
-								return nil, fail("vt index out of range")
-							}
-							f[i].vt = x
-						}
-						// index to vertex normal
-						if len(indices) >= 3 {
-							x, err := strconv.Atoi(indices[2])
-							if err != nil {
-								return nil, fail("bad face vertex normal")
-							}
-							if x > object.Len_VN() {
-								return nil, fail("vn index out of range")
-							}
-							f[i].vn = x
-						}
-					}
-				} else {
-					return nil, fail("bad face indices")
-				}
-			}
-			object.Add_F(&f)
-
-		default:
-			return nil, fail("unrecognized element")
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return &object, nil
-}
-
-//-----------------------------------------------------------------------------
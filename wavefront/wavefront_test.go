@@ -0,0 +1,785 @@
+package wavefront
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+// write an object file into a temporary directory
+func write_obj(t *testing.T, name, data string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// return the area of a triangulated polygon
+func tri_area(p []vec.V3, t [][3]int) float32 {
+	area := float32(0)
+	for _, x := range t {
+		area += p[x[1]].Sub(p[x[0]]).Cross(p[x[2]].Sub(p[x[0]])).Length() / 2
+	}
+	return area
+}
+
+func Test_Triangulate(t *testing.T) {
+	// convex quad
+	quad := []vec.V3{{0, 0, 0}, {2, 0, 0}, {2, 2, 0}, {0, 2, 0}}
+	tri := triangulate(quad)
+	if len(tri) != 2 || tri_area(quad, tri) != 4 {
+		t.Error("FAIL")
+	}
+	// concave "L" shape, the fan from vertex 0 would cover the notch
+	ell := []vec.V3{{2, 1, 0}, {1, 1, 0}, {1, 2, 0}, {0, 2, 0}, {0, 0, 0}, {2, 0, 0}}
+	tri = triangulate(ell)
+	if len(tri) != 4 || tri_area(ell, tri) != 3 {
+		t.Error("FAIL")
+	}
+	// concave polygon with a clockwise winding in the x-z plane
+	cw := []vec.V3{{2, 0, 1}, {2, 0, 0}, {0, 0, 0}, {0, 0, 2}, {1, 0, 2}, {1, 0, 1}}
+	tri = triangulate(cw)
+	if len(tri) != 4 || tri_area(cw, tri) != 3 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Read_Polygons(t *testing.T) {
+	data := "v 0 0 0\nv 2 0 0\nv 2 2 0\nv 0 2 0\nv 1 1 1\nf 1 2 3 4\nf 1 2 5\n"
+	filename := write_obj(t, "quad.obj", data)
+
+	obj, err := Read(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_F() != 2 || obj.Len_FV(0) != 4 || obj.Len_FV(1) != 3 {
+		t.Error("FAIL")
+	}
+
+	obj, err = Read(filename, &Options{Triangulate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_F() != 3 {
+		t.Error("FAIL")
+	}
+	for i := 0; i < obj.Len_F(); i++ {
+		if obj.Len_FV(i) != 3 {
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_Read_Materials(t *testing.T) {
+	obj, err := Read("../obj/gopher.obj", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_Material() != 7 {
+		t.Error("FAIL")
+	}
+	body := obj.Material(obj.Find_Material("Body") - 1)
+	if body.Kd != (vec.V3{0, 0.429367, 0.64}) || body.Ns != 96.078431 || body.Illum != 2 {
+		t.Error("FAIL")
+	}
+	// every face in the gopher has a material
+	for i := 0; i < obj.Len_F(); i++ {
+		if obj.Get_Material(i) == nil {
+			t.Fatal("FAIL")
+		}
+	}
+}
+
+func Test_Read_Texture_Map(t *testing.T) {
+	data := "newmtl skin\nKd 1 0.5 0\nmap_Kd -s 2 2 -clamp on tex/skin diffuse.tga\nbump -bm 0.5 bump.tga\nrefl -type cube_top top.tga\n"
+	filename := write_obj(t, "skin.mtl", data)
+	materials, err := Read_MTL(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := materials[0]
+	if m.Kd != (vec.V3{1, 0.5, 0}) {
+		t.Error("FAIL")
+	}
+	if m.Map_Kd.Filename != "tex/skin diffuse.tga" || !m.Map_Kd.Clamp || m.Map_Kd.Scale != (vec.V3{2, 2, 1}) {
+		t.Error("FAIL")
+	}
+	if m.Map_Kd.Path != filepath.Join(filepath.Dir(filename), "tex/skin diffuse.tga") {
+		t.Error("FAIL")
+	}
+	if m.Map_Bump.Bump_Mult != 0.5 || len(m.Refl) != 1 || m.Refl[0].Reflection != "cube_top" {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Read_Groups(t *testing.T) {
+	data := "v 0 0 0\nv 1 0 0\nv 0 1 0\no a\ng x y\nf 1 2 3\ng y\nf 1 2 3\no b\ng\nf 1 2 3\n"
+	filename := write_obj(t, "groups.obj", data)
+	obj, err := Read(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_Object() != 2 || obj.Len_Group() != 2 {
+		t.Error("FAIL")
+	}
+	if len(obj.Object_Faces("a")) != 2 || len(obj.Object_Faces("b")) != 1 {
+		t.Error("FAIL")
+	}
+	if len(obj.Group_Faces("x")) != 1 || len(obj.Group_Faces("y")) != 2 {
+		t.Error("FAIL")
+	}
+	if g := obj.Get_Groups(0); len(g) != 2 || g[0] != "x" || g[1] != "y" {
+		t.Error("FAIL")
+	}
+	if obj.Get_Object(2) != "b" || len(obj.Get_Groups(2)) != 0 {
+		t.Error("FAIL")
+	}
+	// load a subset
+	obj, err = Read(filename, &Options{Objects: []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_F() != 1 || obj.Get_Object(0) != "b" {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Gopher_Objects(t *testing.T) {
+	obj, err := Read("../obj/gopher.obj", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_Object() != 12 {
+		t.Error("FAIL")
+	}
+	body := obj.Select_Objects("Body_Sphere.002")
+	if body.Len_F() == 0 || body.Len_F() >= obj.Len_F() {
+		t.Error("FAIL")
+	}
+}
+
+// a unit cube with the given smoothing statement
+func cube_obj(smooth string) string {
+	return "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nv 0 0 1\nv 1 0 1\nv 1 1 1\nv 0 1 1\n" + smooth + "\n" +
+		"f 1 4 3 2\nf 5 6 7 8\nf 1 2 6 5\nf 3 4 8 7\nf 1 5 8 4\nf 2 3 7 6\n"
+}
+
+func Test_Gen_Normals(t *testing.T) {
+	// flat shaded cube: 6 distinct face normals
+	obj, err := Read(write_obj(t, "flat.obj", cube_obj("s off")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.Gen_Normals(nil)
+	if obj.Len_VN() != 24 {
+		t.Error("FAIL")
+	}
+	if obj.Get_VN(1, 0).ToV3() != (vec.V3{0, 0, 1}) {
+		t.Error("FAIL")
+	}
+
+	// smooth shaded cube: one normal per corner vertex
+	obj, err = Read(write_obj(t, "smooth.obj", cube_obj("s 1")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.Gen_Normals(&Normal_Options{Weighting: Weight_Angle})
+	if obj.Len_VN() != 8 {
+		t.Error("FAIL")
+	}
+	n := obj.Get_VN(1, 2).ToV3()
+	k := float32(1 / math.Sqrt(3))
+	if n.Sub(vec.V3{k, k, k}).Length() > 1e-6 {
+		t.Error("FAIL")
+	}
+
+	// the crease angle keeps the cube edges sharp
+	obj.Gen_Normals(&Normal_Options{Crease: 60})
+	if obj.Len_VN() != 24 {
+		t.Error("FAIL")
+	}
+}
+
+// read an object, write it back out and read it again
+func round_trip(t *testing.T, filename string) (*Object, *Object) {
+	obj0, err := Read(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.obj")
+	if err := obj0.WriteFile(out, nil); err != nil {
+		t.Fatal(err)
+	}
+	obj1, err := Read(out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj0, obj1
+}
+
+func Test_Round_Trip(t *testing.T) {
+	obj0, obj1 := round_trip(t, "../obj/gopher.obj")
+	if !reflect.DeepEqual(obj0, obj1) {
+		t.Error("FAIL")
+	}
+	obj0, obj1 = round_trip(t, "../obj/african_head.obj")
+	if !reflect.DeepEqual(obj0, obj1) {
+		t.Error("FAIL")
+	}
+	// groups declared out of face order, polygons and w components
+	data := "o unused\nv 0 0 0 2\nv 1 0 0\nv 0 1 0\nv 1 1 0\nvt 0.25 0.5\nvt 1 1 0.5\n" +
+		"g b\ng a b\ns 2\nf 1/1 2/2 4/1 3/2\ng\ns off\nf 1 2 3\n"
+	obj0, obj1 = round_trip(t, write_obj(t, "misc.obj", data))
+	if !reflect.DeepEqual(obj0, obj1) {
+		t.Error("FAIL")
+	}
+
+	// a subset with faces with and without an object, declared out of order
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mixed.mtl"), []byte("newmtl red\nKd 1 0 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data = "mtllib mixed.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nv 1 1 0\ng x\nf 1 2 3\n" +
+		"o a\ng y\nf 1 2 4\no b\nusemtl red\ng x\nf 2 3 4\n"
+	if err := os.WriteFile(filepath.Join(dir, "mixed.obj"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	obj0, err := Read(filepath.Join(dir, "mixed.obj"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj0 = obj0.Select_Groups("x")
+	out := filepath.Join(t.TempDir(), "out.obj")
+	if err := obj0.WriteFile(out, nil); err != nil {
+		t.Fatal(err)
+	}
+	obj1, err = Read(out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj1.Len_F() != 2 || obj1.Len_Object() != 2 {
+		t.Fatal("FAIL")
+	}
+	for i := 0; i < obj0.Len_F(); i++ {
+		if obj0.Get_Object(i) != obj1.Get_Object(i) || !reflect.DeepEqual(obj0.Get_Groups(i), obj1.Get_Groups(i)) {
+			t.Errorf("FAIL face %d", i)
+		}
+		m0, m1 := obj0.Get_Material(i), obj1.Get_Material(i)
+		if (m0 == nil) != (m1 == nil) || (m0 != nil && m0.Name != m1.Name) {
+			t.Errorf("FAIL face %d", i)
+		}
+	}
+
+	// materials need a material library
+	var buf bytes.Buffer
+	if err := obj0.Write(&buf, nil); err == nil {
+		t.Error("FAIL")
+	}
+	if err := obj0.Write(&buf, &Write_Options{MTL: "mixed.mtl"}); err != nil {
+		t.Error(err)
+	}
+	if err := obj0.Select_Objects("a").Write(&buf, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Relative_Indices(t *testing.T) {
+	data := "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 1\nf -3//-1 -2//-1 -1//-1\nv 1 1 0\nf 2 -1 3\n"
+	obj, err := Read(write_obj(t, "rel.obj", data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Get_V(0, 0).ToV3() != (vec.V3{0, 0, 0}) || obj.Get_V(0, 2).ToV3() != (vec.V3{0, 1, 0}) {
+		t.Error("FAIL")
+	}
+	if obj.Get_VN(0, 1).ToV3() != (vec.V3{0, 0, 1}) {
+		t.Error("FAIL")
+	}
+	if obj.Get_V(1, 1).ToV3() != (vec.V3{1, 1, 0}) {
+		t.Error("FAIL")
+	}
+
+	bad := []struct {
+		data string
+		err  string
+	}{
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n", "line 4:3: f: v index is zero"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 -4 2\n", "line 4:5: f: v relative index out of range"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", "line 4:7: f: v index out of range"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nf 1/-2 2/1 3/1\n", "line 5:3: f: vt relative index out of range"},
+	}
+	for _, x := range bad {
+		_, err := Parse(strings.NewReader(x.data), nil)
+		if err == nil || err.Error() != x.err {
+			t.Errorf("FAIL %v", err)
+		}
+	}
+}
+
+func Test_Parse_Errors(t *testing.T) {
+	// strict mode fails on unknown statements
+	data := "v 0 0 0\nv 1 0 0\n  v 0 1 0 # comment\nfoo bar\nf 1 2 3\n"
+	filename := write_obj(t, "foo.obj", data)
+	_, err := Read(filename, nil)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatal("FAIL")
+	}
+	if pe.File != filename || pe.Line != 4 || pe.Column != 1 || pe.Keyword != "foo" {
+		t.Error("FAIL")
+	}
+
+	// lenient mode skips them with a warning
+	var warnings []*ParseError
+	opts := &Options{
+		Lenient: true,
+		Warn:    func(err *ParseError) { warnings = append(warnings, err) },
+	}
+	obj, err := Parse(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_V() != 3 || obj.Len_F() != 1 || len(warnings) != 1 || warnings[0].Line != 4 {
+		t.Error("FAIL")
+	}
+
+	// errors point at the bad field
+	_, err = Parse(strings.NewReader("v 0 0 0\nv 1 0 0\nv 0  x 0\n"), nil)
+	if !errors.As(err, &pe) || pe.Line != 3 || pe.Column != 6 || pe.Keyword != "v" {
+		t.Error("FAIL")
+	}
+	if !strings.Contains(err.Error(), "cannot parse float") {
+		t.Error("FAIL")
+	}
+
+	// errors in a material library are wrapped
+	dir := filepath.Dir(write_obj(t, "bad.mtl", "newmtl a\nKd 1 0\n"))
+	_, err = Parse(strings.NewReader("mtllib bad.mtl\n"), &Options{Dir: dir})
+	if !errors.As(err, &pe) || pe.Keyword != "mtllib" {
+		t.Fatal("FAIL")
+	}
+	var mtl_err *ParseError
+	if !errors.As(pe.Err, &mtl_err) || mtl_err.Line != 2 || mtl_err.Keyword != "Kd" {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Load(t *testing.T) {
+	for _, filename := range []string{"../obj/gopher.obj", "../obj/african_head.obj"} {
+		obj0, err := Read(filename, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj1, err := Load(filename, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(obj0, obj1) {
+			t.Error("FAIL")
+		}
+	}
+
+	// state and relative indices carried across small chunks
+	dir := filepath.Dir(write_obj(t, "m.mtl", "newmtl a\nnewmtl b\n"))
+	data := []byte("mtllib m.mtl\nv 0 0 0\nv 1 0 0\no x\nv 0 1 0\ng p q\nusemtl a\ns 1\n" +
+		"f -3 -2 -1\nfoo\nvt 0 0\nf 1/1 2/1 3/1\n\nusemtl b\n# comment\nf 1 2 3\ng\ns off\nf 3 2 1\nvn 0 0 1\n" +
+		"o y\ng q\nf 1//1 2//1 -1//1\nusemtl a\nf 1 2 3")
+	var w0, w1 []*ParseError
+	obj0, err := Parse(bytes.NewReader(data), &Options{
+		Dir:     dir,
+		Lenient: true,
+		Warn:    func(err *ParseError) { w0 = append(w0, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for size := 1; size < len(data); size += 7 {
+		w1 = nil
+		obj1, err := load(data, "", size, &Options{
+			Dir:     dir,
+			Lenient: true,
+			Warn:    func(err *ParseError) { w1 = append(w1, err) },
+			Workers: 3,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(obj0, obj1) || !reflect.DeepEqual(w0, w1) {
+			t.Fatalf("FAIL chunk size %d", size)
+		}
+	}
+
+	// errors are reported as for a sequential parse
+	data = []byte("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\nf 1 2 3\nf 1 2 4\nf 1 2 x\n")
+	_, err0 := Parse(bytes.NewReader(data), nil)
+	for size := 1; size < len(data); size += 5 {
+		_, err1 := load(data, "", size, &Options{})
+		if err0 == nil || !reflect.DeepEqual(err0, err1) {
+			t.Fatalf("FAIL chunk size %d", size)
+		}
+	}
+}
+
+func Test_Lines_Points(t *testing.T) {
+	data := "v 0 0 0\nv 1 0 0\nv 1 1 0\nvt 0 0\nvt 1 0\no a\nl 1/1 2/2 -1\ng x\np 1 2\n" +
+		"o b\nf 1 2 3\nl 3 1\n"
+	obj, err := Parse(strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Len_L() != 2 || obj.Len_P() != 1 || obj.Len_F() != 1 {
+		t.Fatal("FAIL")
+	}
+	if obj.Len_LV(0) != 3 || obj.Get_LV(0, 2).ToV3() != (vec.V3{1, 1, 0}) {
+		t.Error("FAIL")
+	}
+	if obj.Get_LVT(0, 1).ToV2() != (vec.V2{1, 0}) || obj.Get_LVT(0, 2) != nil {
+		t.Error("FAIL")
+	}
+	if obj.Len_PV(0) != 2 || obj.Get_PV(0, 1).ToV3() != (vec.V3{1, 0, 0}) {
+		t.Error("FAIL")
+	}
+	if s := obj.Select_Objects("b"); s.Len_L() != 1 || s.Len_P() != 0 || s.Len_F() != 1 {
+		t.Error("FAIL")
+	}
+	if s := obj.Select_Groups("x"); s.Len_L() != 1 || s.Len_P() != 1 {
+		t.Error("FAIL")
+	}
+
+	// round trip
+	obj0, obj1 := round_trip(t, write_obj(t, "lines.obj", data))
+	if !reflect.DeepEqual(obj0, obj1) {
+		t.Error("FAIL")
+	}
+
+	// lines and points without an object or material before faces with them
+	dir := t.TempDir()
+	mtl := "newmtl red\nKd 1 0 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "state.mtl"), []byte(mtl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := "mtllib state.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nl 1 2\no a\np 3\nusemtl red\nf 1 2 3\nl 2 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "state.obj"), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+	obj0, obj1 = round_trip(t, filepath.Join(dir, "state.obj"))
+	if !reflect.DeepEqual(obj0, obj1) {
+		t.Error("FAIL")
+	}
+	if obj1.l_list[0].object != 0 || obj1.p_list[0].material != 0 || obj1.l_list[1].material != 1 {
+		t.Error("FAIL")
+	}
+
+	// state carried across chunks
+	for size := 1; size < len(data); size += 3 {
+		obj1, err := load([]byte(data), "", size, &Options{Workers: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(obj, obj1) {
+			t.Fatalf("FAIL chunk size %d", size)
+		}
+	}
+
+	bad := []struct {
+		data string
+		err  string
+	}{
+		{"v 0 0 0\nl 1\n", "line 2:1: l: not enough fields"},
+		{"v 0 0 0\nl 1 1//1\n", "line 2:5: l: bad line indices"},
+		{"v 0 0 0\np 1 2\n", "line 2:5: p: v index out of range"},
+		{"v 0 0 0\np 1/1\n", "line 2:3: p: v index is not an integer"},
+	}
+	for _, x := range bad {
+		_, err := Parse(strings.NewReader(x.data), nil)
+		if err == nil || err.Error() != x.err {
+			t.Errorf("FAIL %v", err)
+		}
+	}
+}
+
+func Test_Mesh(t *testing.T) {
+	obj, err := Read("../obj/gopher.obj", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := obj.Mesh()
+	tri, err := Read("../obj/gopher.obj", &Options{Triangulate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Faces) != tri.Len_F() || len(m.Material) != len(m.Faces) || len(m.Groups) != len(m.Faces) {
+		t.Fatal("FAIL")
+	}
+	if m.UVs != nil || len(m.Normals) != len(m.Positions) {
+		t.Error("FAIL")
+	}
+	for i := range m.Faces {
+		for j := 0; j < 3; j++ {
+			if m.Positions[m.Faces[i][j]] != tri.Get_V(i, j).ToV3() {
+				t.Fatal("FAIL")
+			}
+			if m.Normals[m.Faces[i][j]] != tri.Get_VN(i, j).ToV3() {
+				t.Fatal("FAIL")
+			}
+		}
+		if m.Get_Material(i).Name != tri.Get_Material(i).Name || m.Objects[m.Object[i]] != tri.Get_Object(i) {
+			t.Fatal("FAIL")
+		}
+	}
+
+	// shared vertices, texture coordinates and groups
+	data := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvt 0 0\nvt 1 1\ng a b\nf 1/1 2/1 3/2 4/2\ng\nf 1 2 3\n"
+	obj, err = Parse(strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = obj.Mesh()
+	if len(m.Faces) != 3 || len(m.Positions) != 7 || len(m.UVs) != 7 || m.Normals != nil {
+		t.Fatal("FAIL")
+	}
+	if m.UVs[m.Faces[0][2]] != (vec.V2{1, 1}) || m.Material[0] != -1 || m.Object[0] != -1 {
+		t.Error("FAIL")
+	}
+	if !reflect.DeepEqual(m.Groups[1], []int{0, 1}) || m.Groups[2] != nil {
+		t.Error("FAIL")
+	}
+	if m.Face_Normal(0) != (vec.V3{0, 0, 1}) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+// benchmarks
+
+// The reference reader is the original line by line reader: bufio.Scanner,
+// strings.Fields and a heap allocated element per record. It reads
+// triangles with v, vt and vn only.
+
+type ref_v_elem struct {
+	x [3]float32
+	w float32
+}
+
+type ref_vt_elem struct {
+	x [2]float32
+	w float32
+}
+
+type ref_vn_elem struct {
+	x [3]float32
+}
+
+type ref_object struct {
+	v_list  []*ref_v_elem
+	vt_list []*ref_vt_elem
+	vn_list []*ref_vn_elem
+	f_list  []*[3]F_elem
+}
+
+func reference_read(filename string) (*ref_object, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	line_number := 0
+	scanner := bufio.NewScanner(file)
+
+	fail := func(msg string) error {
+		return fmt.Errorf(msg+" at line %d", line_number)
+	}
+
+	// parse n floats
+	floats := func(x []float32, fields []string) error {
+		for i := range fields {
+			f, err := strconv.ParseFloat(fields[i], 32)
+			if err != nil {
+				return fail("cannot parse float")
+			}
+			x[i] = float32(f)
+		}
+		return nil
+	}
+
+	var object ref_object
+
+	for scanner.Scan() {
+		line_number++
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		n_fields := len(fields)
+
+		if n_fields == 0 {
+			continue
+		}
+
+		n_fields -= 1
+
+		switch fields[0] {
+
+		case "v":
+			if n_fields < 3 || n_fields > 4 {
+				return nil, fail("v: wrong number of fields")
+			}
+			x := [4]float32{0, 0, 0, 1}
+			if err := floats(x[:], fields[1:]); err != nil {
+				return nil, err
+			}
+			v := ref_v_elem{w: x[3]}
+			copy(v.x[:], x[0:3])
+			object.v_list = append(object.v_list, &v)
+
+		case "vt":
+			if n_fields < 2 || n_fields > 3 {
+				return nil, fail("vt: wrong number of fields")
+			}
+			var x [3]float32
+			if err := floats(x[:], fields[1:]); err != nil {
+				return nil, err
+			}
+			vt := ref_vt_elem{w: x[2]}
+			copy(vt.x[:], x[0:2])
+			object.vt_list = append(object.vt_list, &vt)
+
+		case "vn":
+			if n_fields != 3 {
+				return nil, fail("vn: wrong number of fields")
+			}
+			var vn ref_vn_elem
+			if err := floats(vn.x[:], fields[1:]); err != nil {
+				return nil, err
+			}
+			object.vn_list = append(object.vn_list, &vn)
+
+		case "f":
+			if n_fields != 3 {
+				return nil, fail("f: wrong number of fields")
+			}
+			var f [3]F_elem
+			for i := 0; i < 3; i++ {
+				indices := strings.Split(fields[i+1], "/")
+				x, err := strconv.Atoi(indices[0])
+				if err != nil || x > len(object.v_list) {
+					return nil, fail("bad face geometric vertex index")
+				}
+				f[i].v = x
+				if len(indices) >= 2 && len(indices[1]) > 0 {
+					x, err := strconv.Atoi(indices[1])
+					if err != nil || x > len(object.vt_list) {
+						return nil, fail("bad face texture vertex")
+					}
+					f[i].vt = x
+				}
+				if len(indices) >= 3 {
+					x, err := strconv.Atoi(indices[2])
+					if err != nil || x > len(object.vn_list) {
+						return nil, fail("bad face vertex normal")
+					}
+					f[i].vn = x
+				}
+			}
+			object.f_list = append(object.f_list, &f)
+
+		default:
+			return nil, fail("unrecognized element")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// write an n x n vertex grid mesh with texture vertices, normals and triangles
+func write_grid(b *testing.B, n int) string {
+	var buf bytes.Buffer
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			fmt.Fprintf(&buf, "v %g %g %g\n", float32(x)*0.01, float32(y)*0.01, float32((x*y)%7)*0.001)
+			fmt.Fprintf(&buf, "vt %g %g 0\n", float32(x)/float32(n), float32(y)/float32(n))
+			fmt.Fprintf(&buf, "vn 0 0 1\n")
+		}
+	}
+	for y := 0; y < n-1; y++ {
+		for x := 0; x < n-1; x++ {
+			a := y*n + x + 1
+			fmt.Fprintf(&buf, "f %d/%d/%d %d/%d/%d %d/%d/%d\n", a, a, a, a+1, a+1, a+1, a+n, a+n, a+n)
+			fmt.Fprintf(&buf, "f %d/%d/%d %d/%d/%d %d/%d/%d\n", a+1, a+1, a+1, a+n+1, a+n+1, a+n+1, a+n, a+n, a+n)
+		}
+	}
+	filename := filepath.Join(b.TempDir(), "grid.obj")
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return filename
+}
+
+// the grid size for the large mesh benchmarks (180k triangles)
+const bench_grid = 301
+
+// benchmark a reader
+func bench_read(b *testing.B, filename string, read func(filename string) error) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := read(filename); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func bench_reference(filename string) error {
+	_, err := reference_read(filename)
+	return err
+}
+
+func bench_serial(filename string) error {
+	_, err := Read(filename, nil)
+	return err
+}
+
+func bench_parallel(filename string) error {
+	_, err := Load(filename, nil)
+	return err
+}
+
+func Benchmark_Reference_Grid(b *testing.B) {
+	bench_read(b, write_grid(b, bench_grid), bench_reference)
+}
+
+func Benchmark_Read_Grid(b *testing.B) {
+	bench_read(b, write_grid(b, bench_grid), bench_serial)
+}
+
+func Benchmark_Load_Grid(b *testing.B) {
+	bench_read(b, write_grid(b, bench_grid), bench_parallel)
+}
+
+func Benchmark_Read(b *testing.B) {
+	bench_read(b, "../obj/gopher.obj", bench_serial)
+}
+
+func Benchmark_Load(b *testing.B) {
+	bench_read(b, "../obj/gopher.obj", bench_parallel)
+}
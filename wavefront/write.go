@@ -0,0 +1,454 @@
+//-----------------------------------------------------------------------------
+/*
+
+Write Wavefront OBJ and MTL Files
+
+*/
+//-----------------------------------------------------------------------------
+
+package wavefront
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sw_render/vec"
+)
+
+//-----------------------------------------------------------------------------
+
+// Write_Options control how an object is written
+type Write_Options struct {
+	Precision int    // decimal places for floats (0 for the shortest exact representation)
+	MTL       string // material library name for the mtllib statement ("" for none)
+}
+
+type writer struct {
+	w    *bufio.Writer
+	opts *Write_Options
+}
+
+// format a float
+func (w *writer) float(x float32) string {
+	if w.opts.Precision > 0 {
+		return strconv.FormatFloat(float64(x), 'f', w.opts.Precision, 32)
+	}
+	return strconv.FormatFloat(float64(x), 'g', -1, 32)
+}
+
+// format a list of floats
+func (w *writer) floats(x ...float32) string {
+	s := make([]string, len(x))
+	for i := range x {
+		s[i] = w.float(x[i])
+	}
+	return strings.Join(s, " ")
+}
+
+// format a vector
+func (w *writer) v3(x vec.V3) string {
+	return w.floats(x[0], x[1], x[2])
+}
+
+// write a line
+func (w *writer) line(format string, args ...interface{}) {
+	fmt.Fprintf(w.w, format+"\n", args...)
+}
+
+//-----------------------------------------------------------------------------
+// obj files
+
+// return true if the names are first used by the elements in list order
+func names_in_order(names []string, index func(i int) []int, n_elements int) bool {
+	n := 0
+	for i := 0; i < n_elements; i++ {
+		for _, k := range index(i) {
+			if k > n+1 {
+				return false
+			}
+			if k == n+1 {
+				n++
+			}
+		}
+	}
+	return n == len(names)
+}
+
+// return true if two index lists are the same
+func same_indices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// return true if element a has to be written before element b.
+// The object and material can't be set back to none, so elements without them go first.
+func write_before(a, b *Element) bool {
+	return (a.object == 0 && b.object != 0) || (a.material == 0 && b.material != 0)
+}
+
+// an element to write
+type write_element struct {
+	*Element
+	keyword string // f, l or p
+	index   int    // index in the face, line or point list
+}
+
+// return the elements (faces, lines and points) in the order they are written.
+// Faces go before lines and lines before points unless the state needs otherwise.
+func (o *Object) write_order() []write_element {
+	lists := make([][]write_element, 3)
+	for i := range o.f_list {
+		lists[0] = append(lists[0], write_element{&o.f_list[i].Element, "f", i})
+	}
+	for i := range o.l_list {
+		lists[1] = append(lists[1], write_element{&o.l_list[i], "l", i})
+	}
+	for i := range o.p_list {
+		lists[2] = append(lists[2], write_element{&o.p_list[i], "p", i})
+	}
+	list := make([]write_element, 0, len(o.f_list)+len(o.l_list)+len(o.p_list))
+	for {
+		// the first list with a head that no other head has to go before
+		next := -1
+		for i := range lists {
+			if len(lists[i]) == 0 {
+				continue
+			}
+			if next < 0 {
+				next = i
+			}
+			ready := true
+			for j := range lists {
+				if j != i && len(lists[j]) != 0 && write_before(lists[j][0].Element, lists[i][0].Element) {
+					ready = false
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return list
+		}
+		list = append(list, lists[next][0])
+		lists[next] = lists[next][1:]
+	}
+}
+
+// format a list of elements
+func format_elem(elem []F_elem) string {
+	s := make([]string, len(elem))
+	for i, e := range elem {
+		s[i] = e.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// format a face element
+func (e F_elem) String() string {
+	switch {
+	case e.vt == 0 && e.vn == 0:
+		return strconv.Itoa(e.v)
+	case e.vn == 0:
+		return fmt.Sprintf("%d/%d", e.v, e.vt)
+	case e.vt == 0:
+		return fmt.Sprintf("%d//%d", e.v, e.vn)
+	}
+	return fmt.Sprintf("%d/%d/%d", e.v, e.vt, e.vn)
+}
+
+// Write an object in wavefront obj format. Options may be nil.
+// Elements with materials need a material library name (opts.MTL).
+func (o *Object) Write(out io.Writer, opts *Write_Options) error {
+
+	if opts == nil {
+		opts = &Write_Options{}
+	}
+	w := &writer{bufio.NewWriter(out), opts}
+
+	// the object and material can't be set back to none
+	order := o.write_order()
+	for i := 1; i < len(order); i++ {
+		if write_before(order[i].Element, order[i-1].Element) {
+			return fmt.Errorf("%s %d: no object or material after an element with one", order[i].keyword, order[i].index+1)
+		}
+	}
+	for _, e := range order {
+		if e.material != 0 && opts.MTL == "" {
+			return errors.New("elements with materials need a material library name")
+		}
+	}
+
+	if opts.MTL != "" && len(o.mtl_list) != 0 {
+		w.line("mtllib %s", opts.MTL)
+	}
+
+	// declare the object and group names up front if the elements would reorder them.
+	// The object names are declared before the first element with an object.
+	object_index := func(i int) []int {
+		if order[i].object == 0 {
+			return nil
+		}
+		return []int{order[i].object}
+	}
+	group_index := func(i int) []int {
+		return o.element_groups(order[i].Element)
+	}
+	declare_objects := !names_in_order(o.o_names, object_index, len(order))
+	if !names_in_order(o.g_names, group_index, len(order)) {
+		w.line("g %s", strings.Join(o.g_names, " "))
+		w.line("g")
+	}
+
+	for i := 0; i < len(o.v_list); i += v_stride {
+		v := o.v_list[i : i+v_stride]
+		if v[3] != 1 {
+			w.line("v %s", w.floats(v...))
+		} else {
+			w.line("v %s", w.floats(v[:3]...))
+		}
+	}
+
+	for i := 0; i < len(o.vt_list); i += vt_stride {
+		vt := o.vt_list[i : i+vt_stride]
+		if vt[2] != 0 {
+			w.line("vt %s", w.floats(vt...))
+		} else {
+			w.line("vt %s", w.floats(vt[:2]...))
+		}
+	}
+
+	for i := 0; i < len(o.vn_list); i += vn_stride {
+		w.line("vn %s", w.floats(o.vn_list[i:i+vn_stride]...))
+	}
+
+	// current state
+	object := 0
+	material := 0
+	smooth := 0
+	var groups []int
+
+	// write the state changes for an element
+	state := func(e *Element) {
+		if e.object != 0 && declare_objects {
+			for _, name := range o.o_names {
+				w.line("o %s", name)
+			}
+			object = len(o.o_names)
+			declare_objects = false
+		}
+		if e.object != object && e.object != 0 {
+			w.line("o %s", o.o_names[e.object-1])
+			object = e.object
+		}
+		if set := o.element_groups(e); !same_indices(set, groups) {
+			names := make([]string, len(set))
+			for j, k := range set {
+				names[j] = o.g_names[k-1]
+			}
+			w.line("g %s", strings.Join(names, " "))
+			groups = set
+		}
+		if e.material != material && e.material != 0 {
+			w.line("usemtl %s", o.mtl_list[e.material-1].Name)
+			material = e.material
+		}
+	}
+
+	for _, e := range order {
+		state(e.Element)
+		switch e.keyword {
+		case "f":
+			if f := o.f_list[e.index]; f.smooth != smooth {
+				if f.smooth == 0 {
+					w.line("s off")
+				} else {
+					w.line("s %d", f.smooth)
+				}
+				smooth = f.smooth
+			}
+			w.line("f %s", format_elem(o.face_elem(e.index)))
+		case "l":
+			w.line("l %s", format_elem(o.line_elem(e.index)))
+		case "p":
+			w.line("p %s", format_elem(o.point_elem(e.index)))
+		}
+	}
+
+	// object names without elements
+	if declare_objects {
+		for _, name := range o.o_names {
+			w.line("o %s", name)
+		}
+	}
+
+	return w.w.Flush()
+}
+
+//-----------------------------------------------------------------------------
+// mtl files
+
+// format the options and filename of a texture map
+func (w *writer) texture_map(m *Texture_Map) string {
+	d := new_texture_map()
+	on_off := map[bool]string{true: "on", false: "off"}
+	var s []string
+	if m.Blend_U != d.Blend_U {
+		s = append(s, "-blendu", on_off[m.Blend_U])
+	}
+	if m.Blend_V != d.Blend_V {
+		s = append(s, "-blendv", on_off[m.Blend_V])
+	}
+	if m.Bump_Mult != d.Bump_Mult {
+		s = append(s, "-bm", w.float(m.Bump_Mult))
+	}
+	if m.Boost != d.Boost {
+		s = append(s, "-boost", w.float(m.Boost))
+	}
+	if m.Color_Corr != d.Color_Corr {
+		s = append(s, "-cc", on_off[m.Color_Corr])
+	}
+	if m.Clamp != d.Clamp {
+		s = append(s, "-clamp", on_off[m.Clamp])
+	}
+	if m.Channel != d.Channel {
+		s = append(s, "-imfchan", m.Channel)
+	}
+	if m.Base != d.Base || m.Gain != d.Gain {
+		s = append(s, "-mm", w.floats(m.Base, m.Gain))
+	}
+	if m.Offset != d.Offset {
+		s = append(s, "-o", w.v3(m.Offset))
+	}
+	if m.Scale != d.Scale {
+		s = append(s, "-s", w.v3(m.Scale))
+	}
+	if m.Turbulence != d.Turbulence {
+		s = append(s, "-t", w.v3(m.Turbulence))
+	}
+	if m.Resolution != d.Resolution {
+		s = append(s, "-texres", strconv.Itoa(m.Resolution))
+	}
+	if m.Reflection != d.Reflection {
+		s = append(s, "-type", m.Reflection)
+	}
+	s = append(s, m.Filename)
+	return strings.Join(s, " ")
+}
+
+// Write_MTL writes materials in wavefront mtl format. Options may be nil.
+func Write_MTL(out io.Writer, materials []*Material, opts *Write_Options) error {
+
+	if opts == nil {
+		opts = &Write_Options{}
+	}
+	w := &writer{bufio.NewWriter(out), opts}
+	d := new_material("")
+
+	for i, m := range materials {
+		if i != 0 {
+			w.line("")
+		}
+		w.line("newmtl %s", m.Name)
+		w.line("Ns %s", w.float(m.Ns))
+		w.line("Ka %s", w.v3(m.Ka))
+		w.line("Kd %s", w.v3(m.Kd))
+		w.line("Ks %s", w.v3(m.Ks))
+		if m.Ke != d.Ke {
+			w.line("Ke %s", w.v3(m.Ke))
+		}
+		if m.Tf != d.Tf {
+			w.line("Tf %s", w.v3(m.Tf))
+		}
+		w.line("Ni %s", w.float(m.Ni))
+		if m.Halo {
+			w.line("d -halo %s", w.float(m.D))
+		} else {
+			w.line("d %s", w.float(m.D))
+		}
+		if m.Sharpness != d.Sharpness {
+			w.line("sharpness %s", w.float(m.Sharpness))
+		}
+		w.line("illum %d", m.Illum)
+		maps := []struct {
+			keyword string
+			m       *Texture_Map
+		}{
+			{"map_Ka", m.Map_Ka},
+			{"map_Kd", m.Map_Kd},
+			{"map_Ks", m.Map_Ks},
+			{"map_Ns", m.Map_Ns},
+			{"map_d", m.Map_D},
+			{"map_Bump", m.Map_Bump},
+			{"disp", m.Disp},
+			{"decal", m.Decal},
+		}
+		for _, x := range maps {
+			if x.m != nil {
+				w.line("%s %s", x.keyword, w.texture_map(x.m))
+			}
+		}
+		for _, x := range m.Refl {
+			w.line("refl %s", w.texture_map(x))
+		}
+	}
+
+	return w.w.Flush()
+}
+
+//-----------------------------------------------------------------------------
+
+// write a file using a write function
+func write_file(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteFile writes an object to an obj file.
+// If the object has materials they are written to an mtl file with the same base name.
+// Options may be nil.
+func (o *Object) WriteFile(filename string, opts *Write_Options) error {
+
+	if opts == nil {
+		opts = &Write_Options{}
+	}
+	file_opts := *opts
+
+	if len(o.mtl_list) != 0 {
+		mtl_name := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mtl"
+		file_opts.MTL = filepath.Base(mtl_name)
+		err := write_file(mtl_name, func(w io.Writer) error {
+			return Write_MTL(w, o.mtl_list, &file_opts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return write_file(filename, func(w io.Writer) error {
+		return o.Write(w, &file_opts)
+	})
+}
+
+//-----------------------------------------------------------------------------